@@ -3,12 +3,22 @@ package azuredevops
 import (
 	"encoding/json"
 	"go-cloc/devops"
+	"go-cloc/httpclient"
 	"go-cloc/logger"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
+// apiClient is shared by all requests to the Azure DevOps API so the requests per second are capped
+var apiClient = httpclient.New("AzureDevOps", 10)
+
 // Define the nested struct types
 type item struct {
 	Name string `json:"name"`
@@ -18,16 +28,89 @@ type response struct {
 	Value []item `json:"value"`
 }
 
-func CreateCloneURLAzureDevOps(accessToken string, organization string, projectName string, repoName string) string {
-	return "https://" + accessToken + "@dev.azure.com/" + organization + "/" + projectName + "/_git/" + repoName
+type repository struct {
+	Name          string `json:"name"`
+	DefaultBranch string `json:"defaultBranch"`
+	Size          int64  `json:"size"`
+	IsDisabled    bool   `json:"isDisabled"`
+	IsFork        bool   `json:"isFork"`
+	Project       struct {
+		Visibility string `json:"visibility"`
+	} `json:"project"`
+}
+
+type repositoriesResponse struct {
+	Value []repository `json:"value"`
+}
+
+func CreateCloneURLAzureDevOps(organization string, projectName string, repoName string) string {
+	return "https://dev.azure.com/" + organization + "/" + projectName + "/_git/" + repoName
 }
 
-func CreateZipURLAzureDevOps(organization string, projectName string, repoName string, defaultBranch string) string {
-	return "https://dev.azure.com/" + organization + "/" + projectName + "/_apis/git/repositories/" + repoName + "/items/items?path=/&versionDescriptor[versionOptions]=0&versionDescriptor[versionType]=0&versionDescriptor[version]=" + defaultBranch + "&resolveLfs=true&$format=zip&api-version=5.0&download=true"
+// CreateCloneAuthAzureDevOps creates the credentials for cloning over HTTPS, Azure DevOps accepts any username with a PAT
+func CreateCloneAuthAzureDevOps(accessToken string) transport.AuthMethod {
+	return &githttp.BasicAuth{Username: "", Password: accessToken}
+}
+
+func CreateSSHCloneURLAzureDevOps(organization string, projectName string, repoName string) string {
+	// Azure DevOps SSH URLs are not suffixed with .git, spaces in project or repository names are encoded
+	return "git@ssh.dev.azure.com:v3/" + organization + "/" + strings.ReplaceAll(projectName, " ", "%20") + "/" + strings.ReplaceAll(repoName, " ", "%20")
+}
+
+// Version types of the items API, used to download a branch or a tag as zip
+const (
+	BRANCHVERSION string = "0"
+	TAGVERSION    string = "1"
+)
+
+/*
+@versionType BRANCHVERSION or TAGVERSION
+*/
+func CreateZipURLAzureDevOps(organization string, projectName string, repoName string, version string, versionType string) string {
+	return "https://dev.azure.com/" + organization + "/" + projectName + "/_apis/git/repositories/" + repoName + "/items/items?path=/&versionDescriptor[versionOptions]=0&versionDescriptor[versionType]=" + versionType + "&versionDescriptor[version]=" + version + "&resolveLfs=true&$format=zip&api-version=5.0&download=true"
+}
+
+/*
+@continuationToken Value of the x-ms-continuationtoken header from the previous page, empty for the first page
+*/
+func CreateDiscoverProjectsURLAzureDevOps(organization string, pageSize int, continuationToken string) string {
+	apiURL := "https://dev.azure.com/" + organization + "/_apis/projects?api-version=7.0&$top=" + strconv.Itoa(pageSize)
+	if continuationToken != "" {
+		apiURL += "&continuationToken=" + url.QueryEscape(continuationToken)
+	}
+	return apiURL
+}
+
+func CreateDiscoverReposURLAzureDevOps(organization string, projectName string) string {
+	return "https://dev.azure.com/" + organization + "/" + url.PathEscape(projectName) + "/_apis/git/repositories?api-version=7.0"
+}
+
+// TrimBranchRef converts a full reference such as refs/heads/main into the branch name
+func TrimBranchRef(ref string) string {
+	return strings.TrimPrefix(ref, "refs/heads/")
+}
+
+// IncludeProject checks a project name against the include and exclude lists. An empty include list includes every project
+func IncludeProject(projectName string, includeProjects []string, excludeProjects []string) bool {
+	for _, excluded := range excludeProjects {
+		if strings.EqualFold(excluded, projectName) {
+			return false
+		}
+	}
+	if len(includeProjects) == 0 {
+		return true
+	}
+	for _, included := range includeProjects {
+		if strings.EqualFold(included, projectName) {
+			return true
+		}
+	}
+	return false
 }
 
-func DiscoverReposAzureDevOps(organization string, accessToken string) []devops.RepoInfo {
-	apiURL := "https://dev.azure.com/" + organization + "/_apis/projects?api-version=7.0"
+// get performs an authenticated GET request and returns the response body and headers
+func get(apiURL string, accessToken string) ([]byte, http.Header) {
+	logger.Debug("GET: ", apiURL)
 
 	// Create a new HTTP request
 	req, err := http.NewRequest("GET", apiURL, nil)
@@ -38,9 +121,8 @@ func DiscoverReposAzureDevOps(organization string, accessToken string) []devops.
 	// Set basic auth
 	req.SetBasicAuth("", accessToken)
 
-	// Perform the request using the default HTTP client
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Perform the request using the rate limited client
+	resp, err := apiClient.Do(req)
 	if err != nil {
 		log.Fatalf("Failed to fetch data from API: %v", err)
 	}
@@ -57,60 +139,78 @@ func DiscoverReposAzureDevOps(organization string, accessToken string) []devops.
 	if err != nil {
 		log.Fatalf("Failed to read response body: %v", err)
 	}
+	return body, resp.Header
+}
 
-	// Unmarshal the JSON data into the Response struct
-	var r response
-	err = json.Unmarshal([]byte(body), &r)
-	if err != nil {
-		log.Fatalf("Error unmarshalling JSON: %v", err)
-	}
+// discoverProjects pages through all projects in the organization using continuation tokens
+func discoverProjects(organization string, accessToken string) []string {
+	pageSize := 100
+	continuationToken := ""
+	projectNames := []string{}
 
-	repoNames := []devops.RepoInfo{}
-	// Access the nested Name field
-	for _, item := range r.Value {
-		projectName := item.Name
-		logger.Debug("Project Name:", projectName)
+	for {
+		apiURL := CreateDiscoverProjectsURLAzureDevOps(organization, pageSize, continuationToken)
+		body, header := get(apiURL, accessToken)
 
-		apiURL := "https://dev.azure.com/" + organization + "/" + projectName + "/_apis/git/repositories?api-version=7.0"
-		// Create a new HTTP request
-		req, err := http.NewRequest("GET", apiURL, nil)
+		// Unmarshal the JSON data into the Response struct
+		var r response
+		err := json.Unmarshal(body, &r)
 		if err != nil {
-			log.Fatalf("Failed to create HTTP request: %v", err)
+			log.Fatalf("Error unmarshalling JSON: %v", err)
 		}
-
-		// Set basic auth
-		req.SetBasicAuth("", accessToken)
-
-		// Perform the request using the default HTTP client
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			log.Fatalf("Failed to fetch data from API: %v", err)
+		for _, item := range r.Value {
+			projectNames = append(projectNames, item.Name)
 		}
-		defer resp.Body.Close()
 
-		// Check if the status code is 200
-		if resp.StatusCode != http.StatusOK {
-			logger.Error("Unexpected status code: ", resp.StatusCode, ", expected 200")
-			logger.Error("Response: ", resp.Status)
+		// If there is no continuation token, there are no more pages
+		continuationToken = header.Get("x-ms-continuationtoken")
+		if continuationToken == "" {
+			break
 		}
+	}
+	return projectNames
+}
 
-		// Read the response body
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Fatalf("Failed to read response body: %v", err)
+/*
+@includeProjects Only discover repositories in these projects, empty discovers all projects
+@excludeProjects Skip repositories in these projects
+*/
+func DiscoverReposAzureDevOps(organization string, accessToken string, includeProjects []string, excludeProjects []string) []devops.RepoInfo {
+	projectNames := discoverProjects(organization, accessToken)
+	logger.Debug("Discovered ", len(projectNames), " projects in ", organization)
+
+	repoNames := []devops.RepoInfo{}
+	for _, projectName := range projectNames {
+		if !IncludeProject(projectName, includeProjects, excludeProjects) {
+			logger.Debug("Skipping project ", projectName, " based on the include / exclude projects")
+			continue
 		}
+		logger.Debug("Project Name:", projectName)
 
-		// Unmarshal the JSON data into the Response struct
+		body, _ := get(CreateDiscoverReposURLAzureDevOps(organization, projectName), accessToken)
 
-		r := response{}
-		err = json.Unmarshal([]byte(body), &r)
+		// Unmarshal the JSON data into the Response struct
+		r := repositoriesResponse{}
+		err := json.Unmarshal(body, &r)
 		if err != nil {
 			log.Fatalf("Error unmarshalling JSON: %v", err)
 		}
-		for _, item := range r.Value {
-			repoName := item.Name
-			repoInfo := devops.NewRepoInfo(organization, projectName, repoName, "")
+		for _, repo := range r.Value {
+			// disabled repositories cannot be cloned
+			if repo.IsDisabled {
+				logger.Debug("Skipping disabled repository ", projectName, "/", repo.Name)
+				continue
+			}
+			// empty repositories have no default branch and nothing to scan
+			if repo.Size == 0 || repo.DefaultBranch == "" {
+				logger.Debug("Skipping empty repository ", projectName, "/", repo.Name)
+				continue
+			}
+			repoInfo := devops.NewRepoInfo(organization, projectName, repo.Name, TrimBranchRef(repo.DefaultBranch))
+			repoInfo.Fork = repo.IsFork
+			repoInfo.Visibility = strings.ToLower(repo.Project.Visibility)
+			// Azure DevOps reports the size in bytes
+			repoInfo.SizeKB = repo.Size / 1024
 			repoNames = append(repoNames, repoInfo)
 		}
 	}
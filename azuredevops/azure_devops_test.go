@@ -3,16 +3,49 @@ package azuredevops
 import (
 	"testing"
 
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_clone_CreateCloneURL(t *testing.T) {
-	accessToken := "abcdefg"
 	organization := "organization"
 	projectName := "project"
 	repoName := "repo"
-	azdoCloneURL := CreateCloneURLAzureDevOps(accessToken, organization, projectName, repoName)
+	azdoCloneURL := CreateCloneURLAzureDevOps(organization, projectName, repoName)
 
 	// Assert
-	assert.Equal(t, "https://abcdefg@dev.azure.com/organization/project/_git/repo", azdoCloneURL)
+	assert.Equal(t, "https://dev.azure.com/organization/project/_git/repo", azdoCloneURL)
+	assert.Equal(t, &githttp.BasicAuth{Username: "", Password: "abcdefg"}, CreateCloneAuthAzureDevOps("abcdefg"))
+}
+
+func Test_azuredevops_CreateDiscoverProjectsURLAzureDevOps(t *testing.T) {
+	// Assert
+	assert.Equal(t, "https://dev.azure.com/organization/_apis/projects?api-version=7.0&$top=100", CreateDiscoverProjectsURLAzureDevOps("organization", 100, ""))
+	assert.Equal(t, "https://dev.azure.com/organization/_apis/projects?api-version=7.0&$top=100&continuationToken=100", CreateDiscoverProjectsURLAzureDevOps("organization", 100, "100"))
+}
+
+func Test_azuredevops_TrimBranchRef(t *testing.T) {
+	// Assert
+	assert.Equal(t, "main", TrimBranchRef("refs/heads/main"))
+	assert.Equal(t, "feature/login", TrimBranchRef("refs/heads/feature/login"))
+}
+
+func Test_azuredevops_IncludeProject(t *testing.T) {
+	// Assert
+	assert.True(t, IncludeProject("Payments", []string{}, []string{}))
+	assert.True(t, IncludeProject("Payments", []string{"payments"}, []string{}))
+	assert.False(t, IncludeProject("Archive", []string{"Payments"}, []string{}))
+	assert.False(t, IncludeProject("Archive", []string{}, []string{"Archive"}))
+}
+
+func Test_azuredevops_CreateSSHCloneURLAzureDevOps(t *testing.T) {
+	cloneUrl := CreateSSHCloneURLAzureDevOps("organization", "My Project", "repository")
+	// Assert
+	assert.Equal(t, "git@ssh.dev.azure.com:v3/organization/My%20Project/repository", cloneUrl)
+}
+
+func Test_azuredevops_CreateZipURLAzureDevOps(t *testing.T) {
+	// Assert
+	assert.Equal(t, "https://dev.azure.com/organization/project/_apis/git/repositories/repo/items/items?path=/&versionDescriptor[versionOptions]=0&versionDescriptor[versionType]=0&versionDescriptor[version]=main&resolveLfs=true&$format=zip&api-version=5.0&download=true", CreateZipURLAzureDevOps("organization", "project", "repo", "main", BRANCHVERSION))
+	assert.Equal(t, "https://dev.azure.com/organization/project/_apis/git/repositories/repo/items/items?path=/&versionDescriptor[versionOptions]=0&versionDescriptor[versionType]=1&versionDescriptor[version]=v1.2.0&resolveLfs=true&$format=zip&api-version=5.0&download=true", CreateZipURLAzureDevOps("organization", "project", "repo", "v1.2.0", TAGVERSION))
 }
@@ -3,20 +3,39 @@ package bitbucket
 import (
 	"encoding/json"
 	"go-cloc/devops"
+	"go-cloc/httpclient"
 	"go-cloc/logger"
 	"io"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
+// apiClient is shared by all requests to the Bitbucket API so the requests per second are capped. Bitbucket allows 1,000 API requests per hour for repository data
+var apiClient = httpclient.New("Bitbucket", 5)
+
 // Define the nested struct types
 type item struct {
-	Name    string  `json:"name"`
-	Project project `json:"project"`
+	Name       string     `json:"name"`
+	Slug       string     `json:"slug"`
+	Project    project    `json:"project"`
+	MainBranch mainBranch `json:"mainbranch"`
+	IsPrivate  bool       `json:"is_private"`
+	Size       int64      `json:"size"`
+	UpdatedOn  time.Time  `json:"updated_on"`
+	Parent     *struct{}  `json:"parent"`
+	Language   string     `json:"language"`
 }
 type project struct {
 	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+type mainBranch struct {
+	Name string `json:"name"`
 }
 
 type response struct {
@@ -25,26 +44,58 @@ type response struct {
 	Next *string `json:"next"`
 }
 
-func CreateCloneURLBitbucket(accessToken string, organization string, respository string) string {
+/*
+@respository The repository slug, which can differ from the repository name
+*/
+func CreateCloneURLBitbucket(organization string, respository string) string {
 	// Create the URL
-	return "https://x-token-auth:" + accessToken + "@bitbucket.org/" + organization + "/" + respository + ".git"
+	return "https://bitbucket.org/" + organization + "/" + respository + ".git"
 }
 
-func CreateZipURLBitbucket(accessToken string, organization string, repoName string, defaultBranch string) string {
-	logger.Warn("Assuming git repository since using HEAD.zip for the commit")
-	return "https://bitbucket.org/" + organization + "/" + repoName + "/get/HEAD.zip"
+// CreateCloneAuthBitbucket creates the credentials for cloning over HTTPS, access tokens use the x-token-auth user
+func CreateCloneAuthBitbucket(accessToken string) transport.AuthMethod {
+	return &githttp.BasicAuth{Username: "x-token-auth", Password: accessToken}
+}
+
+/*
+@respository The repository slug, which can differ from the repository name
+*/
+func CreateSSHCloneURLBitbucket(organization string, respository string) string {
+	return "git@bitbucket.org:" + organization + "/" + respository + ".git"
+}
+
+/*
+@repoSlug The repository slug, which can differ from the repository name
+*/
+func CreateZipURLBitbucket(organization string, repoSlug string, defaultBranch string) string {
+	if defaultBranch == "" {
+		logger.Warn("No main branch found for ", repoSlug, ", assuming git repository since using HEAD.zip for the commit")
+		defaultBranch = "HEAD"
+	}
+	return "https://bitbucket.org/" + organization + "/" + repoSlug + "/get/" + defaultBranch + ".zip"
 }
 
 func CreateDiscoverURLBitbucket(organization string, pageNum int, pageSize int) string {
 	return "https://api.bitbucket.org/2.0/repositories/" + organization + "?pagelen=" + strconv.Itoa(pageSize) + "&page=" + strconv.Itoa(pageNum)
 }
+
+// CreateRepoInfoBitbucket converts a discovered repository into a RepoInfo, keeping the slug and project key for building URLs
+func CreateRepoInfoBitbucket(organization string, name string, slug string, projectName string, projectKey string, mainBranch string) devops.RepoInfo {
+	repoInfo := devops.NewRepoInfo(organization, projectName, name, mainBranch)
+	if slug != "" {
+		repoInfo.Slug = slug
+	}
+	repoInfo.ProjectKey = projectKey
+	return repoInfo
+}
+
 func DiscoverReposBitbucket(organization string, accessToken string) []devops.RepoInfo {
 	pageSize := 100
-	pageNum := 1
 	repoNames := []devops.RepoInfo{}
 
-	for pageNum != -1 {
-		apiURL := CreateDiscoverURLBitbucket(organization, pageNum, pageSize)
+	// the first page is built from the organization, every following page comes from the next URL of the response
+	apiURL := CreateDiscoverURLBitbucket(organization, 1, pageSize)
+	for apiURL != "" {
 		logger.Debug("Discovering repos using url: ", apiURL)
 
 		// Create a new HTTP request
@@ -56,9 +107,8 @@ func DiscoverReposBitbucket(organization string, accessToken string) []devops.Re
 		// Set basic auth
 		req.Header.Set("Authorization", "Bearer "+accessToken)
 
-		// Perform the request using the default HTTP client
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		// Perform the request using the rate limited client
+		resp, err := apiClient.Do(req)
 		if err != nil {
 			log.Fatalf("Failed to fetch data from API: %v", err)
 		}
@@ -86,14 +136,25 @@ func DiscoverReposBitbucket(organization string, accessToken string) []devops.Re
 		logger.Debug("Response: ", r)
 
 		for _, item := range r.Value {
-			repoInfo := devops.NewRepoInfo(organization, item.Project.Name, item.Name, "")
+			repoInfo := CreateRepoInfoBitbucket(organization, item.Name, item.Slug, item.Project.Name, item.Project.Key, item.MainBranch.Name)
+			repoInfo.Fork = item.Parent != nil
+			repoInfo.Visibility = "public"
+			if item.IsPrivate {
+				repoInfo.Visibility = "private"
+			}
+			// Bitbucket reports the size in bytes
+			repoInfo.SizeKB = item.Size / 1024
+			repoInfo.PrimaryLanguage = item.Language
+			// a repository without a main branch has no commits yet
+			repoInfo.Empty = item.MainBranch.Name == ""
+			repoInfo.LastPushed = item.UpdatedOn
 			repoNames = append(repoNames, repoInfo)
 		}
 		// If there is no next page, stop the loop
 		if r.Next == nil {
-			pageNum = -1
+			apiURL = ""
 		} else {
-			pageNum = pageNum + 1
+			apiURL = *r.Next
 		}
 	}
 
@@ -9,6 +9,8 @@ go mod tidy
 # Set the output directory and binary name
 OUTPUT_DIR="builds"
 BINARY_NAME="go-cloc" # Change this to your program's name
+# Version printed by go-cloc version, defaults to the latest tag
+VERSION="${VERSION:-$(git describe --tags --always 2>/dev/null || echo dev)}"
 mkdir -p $OUTPUT_DIR
 
 # Define platforms and architectures
@@ -32,7 +34,7 @@ for platform in "${platforms[@]}"; do
     fi
     
     echo "Building for $os/$arch..."
-    GOOS=$os GOARCH=$arch go build -o "$output_file" main.go
+    GOOS=$os GOARCH=$arch go build -ldflags "-X go-cloc/utilities.Version=$VERSION" -o "$output_file" main.go
     
     if [ $? -ne 0 ]; then
         echo "Build failed for $os/$arch"
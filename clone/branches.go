@@ -0,0 +1,35 @@
+package clone
+
+import (
+	"go-cloc/logger"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// ListRemoteBranches lists the branch names of a remote repository without cloning it, similar to git ls-remote --heads
+func ListRemoteBranches(url string, auth transport.AuthMethod) ([]string, error) {
+	logger.Debug("Listing branches for url: ", url)
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return nil, err
+	}
+
+	branches := []string{}
+	for _, ref := range refs {
+		if ref.Name().IsBranch() {
+			branches = append(branches, ref.Name().Short())
+		}
+	}
+	sort.Strings(branches)
+	logger.Debug("Found branches: ", branches)
+	return branches, nil
+}
@@ -2,6 +2,7 @@ package clone
 
 import (
 	"archive/zip"
+	"go-cloc/httpclient"
 	"go-cloc/logger"
 	"io"
 	"net/http"
@@ -10,8 +11,13 @@ import (
 	"strings"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 )
 
+// downloadClient is used for zip downloads, which are not capped since each repository is downloaded once
+var downloadClient = httpclient.New("Download", 0)
+
 // Unzip extracts the contents of the zip file to a folder with the same name as the zip file.
 func Unzip(zipFilePath string) error {
 	// Open the zip file
@@ -98,9 +104,8 @@ func DonwloadAndUnzip(getUrl string, repoName string, accessToken string) string
 	req, _ := http.NewRequest("GET", getUrl, nil)
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	// Perform the request using the default HTTP client
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Perform the request, retrying if rate limited
+	resp, err := downloadClient.Do(req)
 	if err != nil {
 		logger.LogStackTraceAndExit(err)
 	}
@@ -150,7 +155,11 @@ func DonwloadAndUnzip(getUrl string, repoName string, accessToken string) string
 	return repoName
 }
 
-func CloneRepo(url string, accessToken string, repoName string) string {
+/*
+@auth Optional authentication, e.g. SSH keys. Nil when the credentials are part of the URL
+@ref Optional branch, tag or full reference name (refs/...) to clone. Empty clones the default branch
+*/
+func CloneRepo(url string, auth transport.AuthMethod, ref string, repoName string) string {
 	// Clone the given repository to the given directory
 	dir := "./" + repoName // Directory where repo will be cloned
 
@@ -158,11 +167,7 @@ func CloneRepo(url string, accessToken string, repoName string) string {
 	// .Printf("Cloning %s into %s...\n", url, dir)
 
 	// Clone repository to specified directory with authentication
-	_, err := git.PlainClone(dir, false, &git.CloneOptions{
-		URL:          url,
-		SingleBranch: true,
-		Depth:        1,
-	})
+	err := shallowClone(dir, url, auth, ref)
 
 	// Check to see if there was an error cloning the repo
 	if err != nil {
@@ -173,3 +178,31 @@ func CloneRepo(url string, accessToken string, repoName string) string {
 	logger.Debug("Repository successfully cloned!")
 	return repoName
 }
+
+// shallowClone clones a single reference with a depth of 1. A short ref name is tried as a branch first, then as a tag
+func shallowClone(dir string, url string, auth transport.AuthMethod, ref string) error {
+	cloneOptions := &git.CloneOptions{
+		URL:          url,
+		Auth:         auth,
+		SingleBranch: true,
+		Depth:        1,
+	}
+	if ref == "" || strings.HasPrefix(ref, "refs/") {
+		cloneOptions.ReferenceName = plumbing.ReferenceName(ref)
+		_, err := git.PlainClone(dir, false, cloneOptions)
+		return err
+	}
+
+	cloneOptions.ReferenceName = plumbing.NewBranchReferenceName(ref)
+	_, err := git.PlainClone(dir, false, cloneOptions)
+	if err == nil {
+		return nil
+	}
+
+	logger.Debug("Branch ", ref, " not found, trying tag: ", err)
+	// remove any partial clone before retrying
+	os.RemoveAll(dir)
+	cloneOptions.ReferenceName = plumbing.NewTagReferenceName(ref)
+	_, err = git.PlainClone(dir, false, cloneOptions)
+	return err
+}
@@ -3,13 +3,15 @@ package clone
 import (
 	"go-cloc/logger"
 
-	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
 	"github.com/go-git/go-git/v5/plumbing/transport"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
-func CloneRepoAzureDevOps(url string, accessToken string, repoName string) string {
+/*
+@auth Basic authentication with the access token for HTTPS, or SSH authentication
+@ref Optional branch, tag or full reference name (refs/...) to clone. Empty clones the default branch
+*/
+func CloneRepoAzureDevOps(url string, auth transport.AuthMethod, ref string, repoName string) string {
 	// Clone the given repository to the given directory
 	dir := "./" + repoName // Directory where repo will be cloned
 
@@ -20,17 +22,12 @@ func CloneRepoAzureDevOps(url string, accessToken string, repoName string) strin
 		capability.ThinPack,
 	}
 
-	_, err := git.PlainClone(dir, false, &git.CloneOptions{
-		Auth: &http.BasicAuth{
-			Username: "",
-			Password: accessToken,
-		},
-		URL: url,
-	})
+	err := shallowClone(dir, url, auth, ref)
 	if err != nil {
-		logger.Error("Failed to clone repo: ", repoName)
-		logger.LogStackTraceAndExit(err)
+		logger.Error("Error cloning repository: ", repoName, " : ", err)
+		return ""
 	}
 
+	logger.Debug("Repository successfully cloned!")
 	return repoName
 }
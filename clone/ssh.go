@@ -0,0 +1,73 @@
+package clone
+
+import (
+	"go-cloc/logger"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"golang.org/x/crypto/ssh"
+)
+
+// Supported clone protocols
+const (
+	HTTPS string = "https"
+	SSH   string = "ssh"
+)
+
+// SSHOptions configures how repositories are cloned over SSH
+type SSHOptions struct {
+	// KeyFilePath is the private key to authenticate with. When empty, the keys loaded in the ssh-agent (SSH_AUTH_SOCK) are used
+	KeyFilePath   string
+	KeyPassphrase string
+	// KnownHostsFilePath overrides the default known_hosts files (SSH_KNOWN_HOSTS or ~/.ssh/known_hosts)
+	KnownHostsFilePath string
+	// StrictHostKeyChecking rejects hosts missing from the known_hosts file when true
+	StrictHostKeyChecking bool
+}
+
+// IsSSHURL checks if the URL is an SSH URL such as git@host:org/repo.git or ssh://git@host/org/repo.git
+func IsSSHURL(url string) bool {
+	if strings.HasPrefix(url, "ssh://") {
+		return true
+	}
+	// scp-like syntax, user@host:path without a scheme
+	return !strings.Contains(url, "://") && strings.Contains(url, "@") && strings.Contains(url, ":")
+}
+
+// CreateSSHAuth creates the go-git SSH authentication from the options
+func CreateSSHAuth(options SSHOptions) (transport.AuthMethod, error) {
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if options.StrictHostKeyChecking {
+		knownHostsFiles := []string{}
+		if options.KnownHostsFilePath != "" {
+			knownHostsFiles = append(knownHostsFiles, options.KnownHostsFilePath)
+		}
+		callback, err := gitssh.NewKnownHostsCallback(knownHostsFiles...)
+		if err != nil {
+			return nil, err
+		}
+		hostKeyCallback = callback
+	} else {
+		logger.Warn("SSH host key checking is disabled")
+	}
+
+	// every provider uses the git user for SSH access
+	if options.KeyFilePath != "" {
+		logger.Debug("Using SSH key ", options.KeyFilePath)
+		auth, err := gitssh.NewPublicKeysFromFile("git", options.KeyFilePath, options.KeyPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		auth.HostKeyCallback = hostKeyCallback
+		return auth, nil
+	}
+
+	logger.Debug("Using the ssh-agent")
+	auth, err := gitssh.NewSSHAgentAuth("git")
+	if err != nil {
+		return nil, err
+	}
+	auth.HostKeyCallback = hostKeyCallback
+	return auth, nil
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go-cloc/devops"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats of a configuration file, detected from its extension
+const (
+	YAML string = "yaml"
+	TOML string = "toml"
+)
+
+// Config describes a run over several sources, e.g. GitHub and two GitLab groups, combined into one report
+type Config struct {
+	Output  Output   `yaml:"output" toml:"output"`
+	Sources []Source `yaml:"sources" toml:"sources"`
+}
+
+// Output holds the settings shared by every source
+type Output struct {
+	ResultsDirectory string `yaml:"resultsDirectory" toml:"resultsDirectory"`
+	// DumpCSVs is true unless set to false
+	DumpCSVs       *bool `yaml:"dumpCsvs" toml:"dumpCsvs"`
+	GroupByProject bool  `yaml:"groupByProject" toml:"groupByProject"`
+}
+
+// Source is a single DevOps organization, or local directory, and how to scan it
+type Source struct {
+	// Name prefixes the id of every repository of the source in the reports, by default the provider in lower case
+	Name string `yaml:"name" toml:"name"`
+	// Provider is the --devops mode, e.g. GitHub or AzureDevOps
+	Provider      string   `yaml:"provider" toml:"provider"`
+	BaseURL       string   `yaml:"baseUrl" toml:"baseUrl"`
+	Organization  string   `yaml:"organization" toml:"organization"`
+	Organizations []string `yaml:"organizations" toml:"organizations"`
+	// Path is the file or directory scanned by the Local and LocalRepositories providers
+	Path             string `yaml:"path" toml:"path"`
+	RepositoriesFile string `yaml:"repositoriesFile" toml:"repositoriesFile"`
+
+	// the access token is referenced, never part of the configuration file
+	TokenEnv  string `yaml:"tokenEnv" toml:"tokenEnv"`
+	TokenFile string `yaml:"tokenFile" toml:"tokenFile"`
+
+	IncludeRepositories     []string `yaml:"includeRepositories" toml:"includeRepositories"`
+	ExcludeRepositories     []string `yaml:"excludeRepositories" toml:"excludeRepositories"`
+	IncludeRepositoriesFile string   `yaml:"includeRepositoriesFile" toml:"includeRepositoriesFile"`
+	ExcludeRepositoriesFile string   `yaml:"excludeRepositoriesFile" toml:"excludeRepositoriesFile"`
+	IncludeProjects         []string `yaml:"includeProjects" toml:"includeProjects"`
+	ExcludeProjects         []string `yaml:"excludeProjects" toml:"excludeProjects"`
+	IgnorePatterns          []string `yaml:"ignorePatterns" toml:"ignorePatterns"`
+	IgnoreFile              string   `yaml:"ignoreFile" toml:"ignoreFile"`
+
+	SkipArchived  bool     `yaml:"skipArchived" toml:"skipArchived"`
+	SkipForks     bool     `yaml:"skipForks" toml:"skipForks"`
+	SkipMirrors   bool     `yaml:"skipMirrors" toml:"skipMirrors"`
+	SkipTemplates bool     `yaml:"skipTemplates" toml:"skipTemplates"`
+	SkipEmpty     bool     `yaml:"skipEmpty" toml:"skipEmpty"`
+	Visibility    []string `yaml:"visibility" toml:"visibility"`
+	// PushedSince is formatted as YYYY-MM-DD
+	PushedSince string `yaml:"pushedSince" toml:"pushedSince"`
+
+	// branch policy, by default the default branch is scanned
+	Ref           string `yaml:"ref" toml:"ref"`
+	Branches      string `yaml:"branches" toml:"branches"`
+	LargestBranch bool   `yaml:"largestBranch" toml:"largestBranch"`
+	CloneProtocol string `yaml:"cloneProtocol" toml:"cloneProtocol"`
+
+	// SSH credentials, the passphrase is referenced like the access token
+	SSHKeyFile          string `yaml:"sshKeyFile" toml:"sshKeyFile"`
+	SSHKeyPassphraseEnv string `yaml:"sshKeyPassphraseEnv" toml:"sshKeyPassphraseEnv"`
+	KnownHosts          string `yaml:"knownHosts" toml:"knownHosts"`
+}
+
+// FormatOf detects the format of a configuration file from its extension
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return YAML, nil
+	case ".toml":
+		return TOML, nil
+	}
+	return "", fmt.Errorf("unsupported configuration file %s, expected a .yaml, .yml or .toml extension", path)
+}
+
+// Load reads and validates a YAML or TOML configuration file. Unknown keys are rejected to catch typos
+func Load(path string) (Config, error) {
+	config := Config{}
+	format, err := FormatOf(path)
+	if err != nil {
+		return config, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+
+	if format == YAML {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil {
+			return config, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	} else {
+		metadata, err := toml.Decode(string(data), &config)
+		if err != nil {
+			return config, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return config, fmt.Errorf("failed to parse %s: unknown key %s", path, undecoded[0])
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return config, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Validate checks every source and sets the default source names
+func (config *Config) Validate() error {
+	if len(config.Sources) == 0 {
+		return errors.New("at least one source is required")
+	}
+	names := map[string]bool{}
+	for index := range config.Sources {
+		source := &config.Sources[index]
+		if source.Provider == "" {
+			return fmt.Errorf("source %d requires a provider", index+1)
+		}
+		if source.Name == "" {
+			source.Name = strings.ToLower(source.Provider)
+		}
+		if names[source.Name] {
+			return fmt.Errorf("source name %s is used more than once, set a unique name for each source", source.Name)
+		}
+		names[source.Name] = true
+		if source.Organization != "" && len(source.Organizations) > 0 {
+			return fmt.Errorf("source %s cannot set both organization and organizations", source.Name)
+		}
+		if _, _, err := source.RepoRules(); err != nil {
+			return fmt.Errorf("source %s: %w", source.Name, err)
+		}
+	}
+	return nil
+}
+
+// RepoRules parses the inline include and exclude repositories rules
+func (source Source) RepoRules() ([]devops.RepoRule, []devops.RepoRule, error) {
+	includeRepositories, err := devops.ParseRepoRules(source.IncludeRepositories)
+	if err != nil {
+		return nil, nil, err
+	}
+	excludeRepositories, err := devops.ParseRepoRules(source.ExcludeRepositories)
+	if err != nil {
+		return nil, nil, err
+	}
+	return includeRepositories, excludeRepositories, nil
+}
+
+// Arguments converts the source into the flags of the scan command. Inline rules and ignore patterns are not flags, see RepoRules
+func (source Source) Arguments() []string {
+	arguments := []string{"--devops=" + source.Provider}
+	addString := func(name string, value string) {
+		if value != "" {
+			arguments = append(arguments, "--"+name+"="+value)
+		}
+	}
+	addBool := func(name string, value bool) {
+		if value {
+			arguments = append(arguments, "--"+name+"=true")
+		}
+	}
+
+	organization := source.Organization
+	if len(source.Organizations) > 0 {
+		organization = strings.Join(source.Organizations, ",")
+	}
+	addString("organization", organization)
+	addString("base-url", source.BaseURL)
+	addString("local-file-path", source.Path)
+	addString("repositories-file", source.RepositoriesFile)
+	addString("access-token-env", source.TokenEnv)
+	addString("access-token-file", source.TokenFile)
+	addString("include-repositories-file", source.IncludeRepositoriesFile)
+	addString("exclude-repositories-file", source.ExcludeRepositoriesFile)
+	addString("include-projects", strings.Join(source.IncludeProjects, ","))
+	addString("exclude-projects", strings.Join(source.ExcludeProjects, ","))
+	addString("ignore-file", source.IgnoreFile)
+	addBool("skip-archived", source.SkipArchived)
+	addBool("skip-forks", source.SkipForks)
+	addBool("skip-mirrors", source.SkipMirrors)
+	addBool("skip-templates", source.SkipTemplates)
+	addBool("skip-empty", source.SkipEmpty)
+	addString("visibility", strings.Join(source.Visibility, ","))
+	addString("pushed-since", source.PushedSince)
+	addString("ref", source.Ref)
+	addString("branches", source.Branches)
+	addBool("largest-branch", source.LargestBranch)
+	addString("clone-protocol", source.CloneProtocol)
+	addString("ssh-key-file", source.SSHKeyFile)
+	addString("ssh-key-passphrase-env", source.SSHKeyPassphraseEnv)
+	addString("ssh-known-hosts-file", source.KnownHosts)
+	return arguments
+}
+
+// Arguments converts the output settings into the flags of the scan command
+func (output Output) Arguments() []string {
+	arguments := []string{}
+	if output.ResultsDirectory != "" {
+		arguments = append(arguments, "--results-directory-path="+output.ResultsDirectory)
+	}
+	if output.DumpCSVs != nil {
+		arguments = append(arguments, "--dump-csvs="+strconv.FormatBool(*output.DumpCSVs))
+	}
+	if output.GroupByProject {
+		arguments = append(arguments, "--group-by-project=true")
+	}
+	return arguments
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	os.WriteFile(path, []byte(content), 0644)
+	return path
+}
+
+func Test_config_Load_yaml(t *testing.T) {
+	path := writeConfig(t, "weekly.yaml", `
+output:
+  resultsDirectory: weekly
+  dumpCsvs: true
+sources:
+  - provider: GitHub
+    organizations: [org1, org2]
+    tokenEnv: WEEKLY_GITHUB_TOKEN
+    skipArchived: true
+    includeRepositories: ["*-service", "!topic:legacy"]
+    ignorePatterns: ["*.json"]
+  - name: platform
+    provider: GitLab
+    organization: company/platform
+    largestBranch: true
+    cloneProtocol: ssh
+    sshKeyFile: keys/platform_ed25519
+    sshKeyPassphraseEnv: PLATFORM_SSH_PASSPHRASE
+    knownHosts: platform_known_hosts
+`)
+
+	config, err := Load(path)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, config.Sources, 2)
+	assert.Equal(t, "github", config.Sources[0].Name)
+	assert.Equal(t, []string{"--devops=GitHub", "--organization=org1,org2", "--access-token-env=WEEKLY_GITHUB_TOKEN", "--skip-archived=true"}, config.Sources[0].Arguments())
+	assert.Equal(t, []string{"--devops=GitLab", "--organization=company/platform", "--largest-branch=true", "--clone-protocol=ssh", "--ssh-key-file=keys/platform_ed25519", "--ssh-key-passphrase-env=PLATFORM_SSH_PASSPHRASE", "--ssh-known-hosts-file=platform_known_hosts"}, config.Sources[1].Arguments())
+	assert.Equal(t, []string{"--results-directory-path=weekly", "--dump-csvs=true"}, config.Output.Arguments())
+
+	includeRepositories, excludeRepositories, err := config.Sources[0].RepoRules()
+	assert.NoError(t, err)
+	assert.Len(t, includeRepositories, 2)
+	assert.Len(t, excludeRepositories, 0)
+}
+
+func Test_config_Load_toml(t *testing.T) {
+	path := writeConfig(t, "weekly.toml", `
+[output]
+groupByProject = true
+
+[[sources]]
+provider = "AzureDevOps"
+organization = "company"
+tokenFile = "azure-token.txt"
+excludeProjects = ["Sandbox"]
+pushedSince = "2024-01-01"
+`)
+
+	config, err := Load(path)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--devops=AzureDevOps", "--organization=company", "--access-token-file=azure-token.txt", "--exclude-projects=Sandbox", "--pushed-since=2024-01-01"}, config.Sources[0].Arguments())
+	assert.Equal(t, []string{"--group-by-project=true"}, config.Output.Arguments())
+}
+
+func Test_config_Load_invalid(t *testing.T) {
+	for _, testCase := range []struct {
+		name    string
+		content string
+	}{
+		{"unknown-key.yaml", "sources:\n  - provider: GitHub\n    organisation: typo\n"},
+		{"unknown-key.toml", "[[sources]]\nprovider = \"GitHub\"\norganisation = \"typo\"\n"},
+		{"no-sources.yaml", "output:\n  dumpCsvs: false\n"},
+		{"no-provider.yaml", "sources:\n  - organization: org\n"},
+		{"duplicate-names.yaml", "sources:\n  - provider: GitLab\n  - provider: GitLab\n"},
+		{"invalid-rule.yaml", "sources:\n  - provider: GitHub\n    excludeRepositories: [\"re:(\"]\n"},
+		{"config.json", "{}"},
+	} {
+		_, err := Load(writeConfig(t, testCase.name, testCase.content))
+		// Assert
+		assert.Error(t, err, testCase.name)
+	}
+}
@@ -0,0 +1,54 @@
+package devops
+
+import (
+	"strings"
+	"time"
+)
+
+// RepoFilter describes which repositories to skip based on the metadata reported by the DevOps platform
+type RepoFilter struct {
+	SkipArchived  bool
+	SkipForks     bool
+	SkipMirrors   bool
+	SkipTemplates bool
+	SkipEmpty     bool
+	// Visibilities to keep, e.g. private. Empty keeps every visibility
+	Visibilities []string
+	// Repositories last pushed before this time are skipped. The zero value keeps every repository
+	PushedSince time.Time
+}
+
+// ExcludeReason returns why the repository should be skipped, or an empty string if it should be kept
+func (filter RepoFilter) ExcludeReason(repoInfo RepoInfo) string {
+	if filter.SkipArchived && repoInfo.Archived {
+		return "it is archived"
+	}
+	if filter.SkipForks && repoInfo.Fork {
+		return "it is a fork"
+	}
+	if filter.SkipMirrors && repoInfo.Mirror {
+		return "it is a mirror"
+	}
+	if filter.SkipTemplates && repoInfo.Template {
+		return "it is a template"
+	}
+	if filter.SkipEmpty && repoInfo.Empty {
+		return "it is empty"
+	}
+	if len(filter.Visibilities) > 0 && repoInfo.Visibility != "" {
+		found := false
+		for _, visibility := range filter.Visibilities {
+			if strings.EqualFold(visibility, repoInfo.Visibility) {
+				found = true
+			}
+		}
+		if !found {
+			return "its visibility is " + repoInfo.Visibility
+		}
+	}
+	// repositories without a known push date are kept
+	if !filter.PushedSince.IsZero() && !repoInfo.LastPushed.IsZero() && repoInfo.LastPushed.Before(filter.PushedSince) {
+		return "it was last pushed " + repoInfo.LastPushed.Format(time.DateOnly)
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package devops
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_devops_ExcludeReason(t *testing.T) {
+	active := NewRepoInfo("org", "", "active", "main")
+	active.Visibility = "private"
+	active.LastPushed = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	archived := NewRepoInfo("org", "", "archived", "main")
+	archived.Archived = true
+	fork := NewRepoInfo("org", "", "fork", "main")
+	fork.Fork = true
+	public := NewRepoInfo("org", "", "public", "main")
+	public.Visibility = "public"
+	stale := NewRepoInfo("org", "", "stale", "main")
+	stale.LastPushed = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	unknown := NewRepoInfo("org", "", "unknown", "main")
+
+	filter := RepoFilter{
+		SkipArchived: true,
+		SkipForks:    true,
+		Visibilities: []string{"Private"},
+		PushedSince:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Assert
+	assert.Equal(t, "", filter.ExcludeReason(active))
+	assert.Equal(t, "it is archived", filter.ExcludeReason(archived))
+	assert.Equal(t, "it is a fork", filter.ExcludeReason(fork))
+	assert.Equal(t, "its visibility is public", filter.ExcludeReason(public))
+	assert.Equal(t, "it was last pushed 2024-01-01", filter.ExcludeReason(stale))
+	assert.Equal(t, "", filter.ExcludeReason(unknown))
+}
@@ -1,11 +1,45 @@
 package devops
 
+import (
+	"strings"
+	"time"
+)
+
 type RepoInfo struct {
 	Id               string
 	RepositoryName   string
 	OrganizationName string
 	ProjectName      string
 	DefaultBranch    string
+	// Namespace is the full path the repository lives under, e.g. GitLab group/subgroup. Defaults to the organization
+	Namespace string
+	// Slug is the URL safe repository name used in clone and archive URLs. Defaults to the repository name
+	Slug string
+	// ProjectKey is the short key of the project, e.g. Bitbucket project keys
+	ProjectKey string
+	// CloneURL is set when the repository was not discovered through a DevOps API
+	CloneURL string
+	// LocalPath is set when the repository is already checked out on disk
+	LocalPath string
+
+	// Metadata reported by the DevOps platform, used to filter repositories before cloning.
+	// Platforms that do not report a value leave it at its zero value
+	Archived   bool
+	Fork       bool
+	Mirror     bool
+	Template   bool
+	Empty      bool
+	Visibility string // public, private or internal
+	SizeKB     int64
+	LastPushed time.Time
+	Topics     []string
+	// PrimaryLanguage is the main language detected by the DevOps platform
+	PrimaryLanguage string
+}
+
+// FlattenPath replaces the path separators of a name, since the Id and the repository name are used as file and directory names
+func FlattenPath(name string) string {
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(name)
 }
 
 /*
@@ -19,9 +53,12 @@ func NewRepoInfo(organization string, project string, repositoryName string, def
 	} else {
 		repoInfo.Id = organization + "-" + project + "-" + repositoryName
 	}
+	repoInfo.Id = FlattenPath(repoInfo.Id)
 	repoInfo.ProjectName = project
 	repoInfo.OrganizationName = organization
 	repoInfo.RepositoryName = repositoryName
 	repoInfo.DefaultBranch = defaultBranch
+	repoInfo.Namespace = organization
+	repoInfo.Slug = repositoryName
 	return repoInfo
 }
@@ -0,0 +1,158 @@
+package devops
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Fields a rule can be restricted to, e.g. topic:legacy-*
+const (
+	RuleFieldAny     = ""
+	RuleFieldName    = "name"
+	RuleFieldId      = "id"
+	RuleFieldProject = "project"
+	RuleFieldTopic   = "topic"
+)
+
+// RepoRule is a single line of an include or exclude repositories file.
+//
+// A line has the format [!][field:][re:]pattern where
+//   - ! negates the rule, so a later line can undo an earlier match
+//   - field is one of name, id, project or topic. Without a field the name, id and full path are matched
+//   - re: treats the pattern as a regular expression, otherwise it is a case-insensitive glob using * and ?
+type RepoRule struct {
+	Line    string
+	Negated bool
+	Field   string
+	pattern *regexp.Regexp
+}
+
+// convertGlobToRegex converts a glob such as my-*-service into an anchored, case-insensitive regular expression
+func convertGlobToRegex(glob string) string {
+	var builder strings.Builder
+	builder.WriteString("(?i)^")
+	for _, char := range glob {
+		switch char {
+		case '*':
+			builder.WriteString(".*")
+		case '?':
+			builder.WriteString(".")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	builder.WriteString("$")
+	return builder.String()
+}
+
+// CompileGlob compiles a case-insensitive glob using * and ? into a regular expression that matches the whole value
+func CompileGlob(glob string) *regexp.Regexp {
+	// every character other than the wildcards is quoted, so the expression is always valid
+	return regexp.MustCompile(convertGlobToRegex(glob))
+}
+
+// ParseRepoRule parses a single, non-empty line of an include or exclude repositories file
+func ParseRepoRule(line string) (RepoRule, error) {
+	rule := RepoRule{Line: line, Field: RuleFieldAny}
+	pattern := line
+
+	if strings.HasPrefix(pattern, "!") {
+		rule.Negated = true
+		pattern = pattern[1:]
+	}
+	for _, field := range []string{RuleFieldName, RuleFieldId, RuleFieldProject, RuleFieldTopic} {
+		if strings.HasPrefix(pattern, field+":") {
+			rule.Field = field
+			pattern = strings.TrimPrefix(pattern, field+":")
+			break
+		}
+	}
+
+	expression := ""
+	if strings.HasPrefix(pattern, "re:") {
+		expression = strings.TrimPrefix(pattern, "re:")
+	} else {
+		expression = convertGlobToRegex(pattern)
+	}
+	compiled, err := regexp.Compile(expression)
+	if err != nil {
+		return rule, fmt.Errorf("invalid rule %q: %w", line, err)
+	}
+	rule.pattern = compiled
+	return rule, nil
+}
+
+// ParseRepoRules parses the lines of an include or exclude repositories file, skipping comments starting with #
+func ParseRepoRules(lines []string) ([]RepoRule, error) {
+	rules := []RepoRule{}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		rule, err := ParseRepoRule(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Matches checks if the rule pattern matches the repository, ignoring negation
+func (rule RepoRule) Matches(repoInfo RepoInfo) bool {
+	candidates := []string{}
+	switch rule.Field {
+	case RuleFieldName:
+		candidates = append(candidates, repoInfo.RepositoryName)
+	case RuleFieldId:
+		candidates = append(candidates, repoInfo.Id)
+	case RuleFieldProject:
+		candidates = append(candidates, repoInfo.ProjectName)
+	case RuleFieldTopic:
+		candidates = append(candidates, repoInfo.Topics...)
+	default:
+		candidates = append(candidates, repoInfo.RepositoryName, repoInfo.Id, repoInfo.Namespace+"/"+repoInfo.Slug)
+	}
+	for _, candidate := range candidates {
+		if rule.pattern.MatchString(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchRepoRules returns the last rule matching the repository, so later lines take precedence.
+// The boolean is true when the last matching rule is not negated
+func MatchRepoRules(rules []RepoRule, repoInfo RepoInfo) (bool, *RepoRule) {
+	var lastMatch *RepoRule
+	for index := range rules {
+		if rules[index].Matches(repoInfo) {
+			lastMatch = &rules[index]
+		}
+	}
+	if lastMatch == nil {
+		return false, nil
+	}
+	return !lastMatch.Negated, lastMatch
+}
+
+// EvaluateRepoRules decides if a repository is scanned based on the include and exclude rules.
+// Returns the decision and a human readable reason
+func EvaluateRepoRules(repoInfo RepoInfo, includeRules []RepoRule, excludeRules []RepoRule) (bool, string) {
+	if excluded, rule := MatchRepoRules(excludeRules, repoInfo); excluded {
+		return false, fmt.Sprintf("excluded by exclude rule %q", rule.Line)
+	}
+	if len(includeRules) == 0 {
+		return true, "included as there are no include rules"
+	}
+	included, rule := MatchRepoRules(includeRules, repoInfo)
+	if included {
+		return true, fmt.Sprintf("included by include rule %q", rule.Line)
+	}
+	if rule != nil {
+		return false, fmt.Sprintf("excluded by negated include rule %q", rule.Line)
+	}
+	return false, "excluded as it does not match any include rule"
+}
@@ -0,0 +1,56 @@
+package devops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_devops_ParseRepoRules_invalid_regex(t *testing.T) {
+	_, err := ParseRepoRules([]string{"re:("})
+
+	// Assert
+	assert.Error(t, err)
+}
+
+func Test_devops_MatchRepoRules(t *testing.T) {
+	service := NewRepoInfo("org", "Payments", "billing-service", "main")
+	service.Topics = []string{"java", "legacy"}
+	tool := NewRepoInfo("org", "Tools", "build-tool", "main")
+
+	rules, err := ParseRepoRules([]string{
+		"# services, except the legacy ones",
+		"*-SERVICE",
+		"!topic:legacy",
+		"project:re:^Too",
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(rules))
+	matched, rule := MatchRepoRules(rules, service)
+	assert.False(t, matched)
+	assert.Equal(t, "!topic:legacy", rule.Line)
+	matched, rule = MatchRepoRules(rules, tool)
+	assert.True(t, matched)
+	assert.Equal(t, "project:re:^Too", rule.Line)
+}
+
+func Test_devops_EvaluateRepoRules(t *testing.T) {
+	repoInfo := NewRepoInfo("org", "", "billing-service", "main")
+	includeRules, _ := ParseRepoRules([]string{"id:org-billing-*"})
+	excludeRules, _ := ParseRepoRules([]string{"*-test"})
+
+	included, reason := EvaluateRepoRules(repoInfo, includeRules, excludeRules)
+
+	// Assert
+	assert.True(t, included)
+	assert.Equal(t, `included by include rule "id:org-billing-*"`, reason)
+
+	included, reason = EvaluateRepoRules(NewRepoInfo("org", "", "billing-test", "main"), includeRules, excludeRules)
+	assert.False(t, included)
+	assert.Equal(t, `excluded by exclude rule "*-test"`, reason)
+
+	included, _ = EvaluateRepoRules(NewRepoInfo("org", "", "other", "main"), includeRules, excludeRules)
+	assert.False(t, included)
+}
@@ -0,0 +1,294 @@
+package estimate
+
+import (
+	"encoding/csv"
+	"fmt"
+	"go-cloc/scanner"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DEFAULTBYTESPERLINE converts the bytes of languages without a factor, e.g. repositories whose language is unknown
+const DEFAULTBYTESPERLINE float64 = 35
+
+// UNKNOWN is the language reported for bytes the DevOps platform could not attribute to a language
+const UNKNOWN string = "unknown"
+
+// Factors are the bytes per line of code of each language, keyed by CanonicalLanguage
+type Factors map[string]float64
+
+// defaultFactors are rough averages of the bytes per line of code, including the comments and blank lines between them
+var defaultFactors = Factors{
+	"actionscript":      32,
+	"abap":              38,
+	"apex":              38,
+	"c":                 30,
+	"c++":               32,
+	"cobol":             55,
+	"c#":                38,
+	"css":               26,
+	"golang":            30,
+	"html":              45,
+	"java":              38,
+	"javascript":        34,
+	"kotlin":            36,
+	"flex":              36,
+	"php":               36,
+	"objective-c":       40,
+	"oracle pl/sql":     40,
+	"pl/i":              45,
+	"python":            34,
+	"rpg":               55,
+	"ruby":              28,
+	"scala":             36,
+	"scss":              26,
+	"sql":               40,
+	"swift":             36,
+	"typescript":        34,
+	"t-sql":             40,
+	"vue":               34,
+	"visual basic .net": 40,
+	"xml":               48,
+	"xhtml":             45,
+	"yaml":              24,
+	"terraform":         28,
+	"jcl":               55,
+}
+
+// aliases maps the language names used by GitHub, GitLab, Bitbucket and Gitea to the names used by the scanner
+var aliases = map[string]string{
+	"go":           "golang",
+	"plsql":        "oracle pl/sql",
+	"tsql":         "t-sql",
+	"hcl":          "terraform",
+	"rpgle":        "rpg",
+	"csharp":       "c#",
+	"cpp":          "c++",
+	"vb.net":       "visual basic .net",
+	"visual basic": "visual basic .net",
+	"c header":     "c",
+	"c++ header":   "c++",
+}
+
+// LanguageEstimate is the estimated LOC of a single language in a repository
+type LanguageEstimate struct {
+	Language      string
+	Bytes         int64
+	BytesPerLine  float64
+	CodeLineCount int
+}
+
+// RepoEstimate is the estimated LOC per language of a repository
+type RepoEstimate struct {
+	RepositoryId string
+	Languages    []LanguageEstimate
+}
+
+// LanguageTotal is the measured size and LOC of a language, used to calibrate the factors
+type LanguageTotal struct {
+	Bytes         int64
+	CodeLineCount int
+}
+
+// Calibration sums the bytes and LOC per language of real scans, keyed by CanonicalLanguage
+type Calibration map[string]*LanguageTotal
+
+// CanonicalLanguage converts a language name from the scanner or a DevOps platform into the key used by Factors
+func CanonicalLanguage(language string) string {
+	canonical := strings.ToLower(strings.TrimSpace(language))
+	if alias, ok := aliases[canonical]; ok {
+		return alias
+	}
+	return canonical
+}
+
+// IsSupportedLanguage checks if the scanner counts the language, other languages are left out of estimates
+func IsSupportedLanguage(language string) bool {
+	canonical := CanonicalLanguage(language)
+	for scannerLanguage := range scanner.Languages {
+		if CanonicalLanguage(scannerLanguage) == canonical {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultFactors returns a copy of the built-in bytes per line of code
+func DefaultFactors() Factors {
+	factors := Factors{}
+	for language, bytesPerLine := range defaultFactors {
+		factors[language] = bytesPerLine
+	}
+	return factors
+}
+
+// BytesPerLine returns the factor of the language, or DEFAULTBYTESPERLINE if there is none
+func (factors Factors) BytesPerLine(language string) float64 {
+	if bytesPerLine, ok := factors[CanonicalLanguage(language)]; ok && bytesPerLine > 0 {
+		return bytesPerLine
+	}
+	return DEFAULTBYTESPERLINE
+}
+
+// ApportionBytes splits the size of a repository using the percentage of each language, as reported by GitLab
+func ApportionBytes(percentages map[string]float64, sizeBytes int64) map[string]int64 {
+	languageBytes := map[string]int64{}
+	for language, percentage := range percentages {
+		languageBytes[language] = int64(math.Round(float64(sizeBytes) * percentage / 100))
+	}
+	return languageBytes
+}
+
+// EstimateLanguages converts the bytes of each language into LOC, sorted by LOC in descending order.
+// Languages the scanner does not count are skipped, an empty language is estimated as UNKNOWN
+func EstimateLanguages(languageBytes map[string]int64, factors Factors) []LanguageEstimate {
+	estimates := []LanguageEstimate{}
+	for language, bytes := range languageBytes {
+		bytesPerLine := DEFAULTBYTESPERLINE
+		if language == "" {
+			language = UNKNOWN
+		} else if !IsSupportedLanguage(language) {
+			continue
+		} else {
+			bytesPerLine = factors.BytesPerLine(language)
+		}
+		estimates = append(estimates, LanguageEstimate{
+			Language:      language,
+			Bytes:         bytes,
+			BytesPerLine:  bytesPerLine,
+			CodeLineCount: int(math.Round(float64(bytes) / bytesPerLine)),
+		})
+	}
+
+	// Sort by CodeLineCount desc, then by name to keep the output stable
+	sort.Slice(estimates, func(a, b int) bool {
+		if estimates[a].CodeLineCount == estimates[b].CodeLineCount {
+			return estimates[a].Language < estimates[b].Language
+		}
+		return estimates[a].CodeLineCount > estimates[b].CodeLineCount
+	})
+	return estimates
+}
+
+// TotalCodeLineCount sums the estimated LOC of all languages
+func TotalCodeLineCount(estimates []LanguageEstimate) int {
+	total := 0
+	for _, estimate := range estimates {
+		total += estimate.CodeLineCount
+	}
+	return total
+}
+
+// Add sums the bytes and LOC of the scanned files per language
+func (calibration Calibration) Add(fileScanResultsArr []scanner.FileScanResults) {
+	for _, results := range fileScanResultsArr {
+		if results.Language == "" {
+			continue
+		}
+		language := CanonicalLanguage(results.Language)
+		if _, ok := calibration[language]; !ok {
+			calibration[language] = &LanguageTotal{}
+		}
+		calibration[language].Bytes += results.Bytes
+		calibration[language].CodeLineCount += results.CodeLineCount
+	}
+}
+
+// Factors returns the measured bytes per line of code of every language with LOC
+func (calibration Calibration) Factors() Factors {
+	factors := Factors{}
+	for language, total := range calibration {
+		if total.CodeLineCount > 0 {
+			factors[language] = float64(total.Bytes) / float64(total.CodeLineCount)
+		}
+	}
+	return factors
+}
+
+// ConvertCalibrationIntoRecords creates the records of the calibration file, which is read again by LoadFactors
+func ConvertCalibrationIntoRecords(calibration Calibration) [][]string {
+	languages := []string{}
+	for language := range calibration {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+
+	factors := calibration.Factors()
+	records := [][]string{
+		{"language", "bytes", "lineOfCodeCount", "bytesPerLine"},
+	}
+	for _, language := range languages {
+		total := calibration[language]
+		bytesPerLine := ""
+		if factor, ok := factors[language]; ok {
+			bytesPerLine = strconv.FormatFloat(factor, 'f', 2, 64)
+		}
+		records = append(records, []string{language, strconv.FormatInt(total.Bytes, 10), strconv.Itoa(total.CodeLineCount), bytesPerLine})
+	}
+	return records
+}
+
+// ConvertEstimatesIntoRecords creates records listing the estimated LOC of every language of every repository
+func ConvertEstimatesIntoRecords(repoEstimates []RepoEstimate) [][]string {
+	records := [][]string{
+		{"repository", "language", "bytes", "bytesPerLine", "estimatedLineOfCodeCount"},
+	}
+	for _, repoEstimate := range repoEstimates {
+		for _, estimate := range repoEstimate.Languages {
+			row := []string{repoEstimate.RepositoryId, estimate.Language, strconv.FormatInt(estimate.Bytes, 10), strconv.FormatFloat(estimate.BytesPerLine, 'f', 2, 64), strconv.Itoa(estimate.CodeLineCount)}
+			records = append(records, row)
+		}
+	}
+	return records
+}
+
+// LoadFactors returns the default factors, overridden by the bytesPerLine column of a calibration file if a path is given
+func LoadFactors(path string) (Factors, error) {
+	factors := DefaultFactors()
+	if path == "" {
+		return factors, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse calibration file %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("calibration file %s is empty", path)
+	}
+
+	// find the columns by name
+	languageColumn, bytesPerLineColumn := -1, -1
+	for index, column := range records[0] {
+		switch strings.TrimSpace(column) {
+		case "language":
+			languageColumn = index
+		case "bytesPerLine":
+			bytesPerLineColumn = index
+		}
+	}
+	if languageColumn == -1 || bytesPerLineColumn == -1 {
+		return nil, fmt.Errorf("calibration file %s requires the columns language and bytesPerLine", path)
+	}
+
+	for _, record := range records[1:] {
+		if len(record) <= languageColumn || len(record) <= bytesPerLineColumn {
+			continue
+		}
+		bytesPerLine, err := strconv.ParseFloat(strings.TrimSpace(record[bytesPerLineColumn]), 64)
+		// languages without LOC have no factor
+		if err != nil || bytesPerLine <= 0 {
+			continue
+		}
+		factors[CanonicalLanguage(record[languageColumn])] = bytesPerLine
+	}
+	return factors, nil
+}
@@ -0,0 +1,81 @@
+package estimate
+
+import (
+	"go-cloc/scanner"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_estimate_EstimateLanguages(t *testing.T) {
+	languageBytes := map[string]int64{
+		"Go":       3000,
+		"Java":     3800,
+		"Markdown": 5000,
+		"":         350,
+	}
+
+	estimates := EstimateLanguages(languageBytes, DefaultFactors())
+
+	// Assert
+	assert.Equal(t, []LanguageEstimate{
+		{Language: "Go", Bytes: 3000, BytesPerLine: 30, CodeLineCount: 100},
+		{Language: "Java", Bytes: 3800, BytesPerLine: 38, CodeLineCount: 100},
+		{Language: UNKNOWN, Bytes: 350, BytesPerLine: DEFAULTBYTESPERLINE, CodeLineCount: 10},
+	}, estimates)
+	assert.Equal(t, 210, TotalCodeLineCount(estimates))
+}
+
+func Test_estimate_CanonicalLanguage(t *testing.T) {
+	assert.Equal(t, "golang", CanonicalLanguage("Go"))
+	assert.Equal(t, "golang", CanonicalLanguage("Golang"))
+	assert.Equal(t, "oracle pl/sql", CanonicalLanguage("PLSQL"))
+	assert.Equal(t, "c", CanonicalLanguage("C Header"))
+	assert.True(t, IsSupportedLanguage("TSQL"))
+	assert.False(t, IsSupportedLanguage("Shell"))
+}
+
+func Test_estimate_ApportionBytes(t *testing.T) {
+	languageBytes := ApportionBytes(map[string]float64{"Go": 75, "Python": 25}, 4000)
+	// Assert
+	assert.Equal(t, map[string]int64{"Go": 3000, "Python": 1000}, languageBytes)
+}
+
+func Test_estimate_Calibration(t *testing.T) {
+	calibration := Calibration{}
+	calibration.Add([]scanner.FileScanResults{
+		{FilePath: "main.go", Language: "Golang", Bytes: 2000, CodeLineCount: 50},
+		{FilePath: "util.go", Language: "Golang", Bytes: 1000, CodeLineCount: 25},
+		{FilePath: "empty.py", Language: "Python", Bytes: 10, CodeLineCount: 0},
+		{FilePath: "README.md", Bytes: 500},
+	})
+	records := ConvertCalibrationIntoRecords(calibration)
+
+	// Assert
+	assert.Equal(t, [][]string{
+		{"language", "bytes", "lineOfCodeCount", "bytesPerLine"},
+		{"golang", "3000", "75", "40.00"},
+		{"python", "10", "0", ""},
+	}, records)
+
+	// the calibration file overrides the default factors
+	calibrationFilePath := filepath.Join(t.TempDir(), "AAA-bytes-per-line.csv")
+	os.WriteFile(calibrationFilePath, []byte("language,bytes,lineOfCodeCount,bytesPerLine\ngolang,3000,75,40.00\npython,10,0,\n"), 0644)
+	factors, err := LoadFactors(calibrationFilePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 40.0, factors.BytesPerLine("Go"))
+	assert.Equal(t, 34.0, factors.BytesPerLine("Python"))
+}
+
+func Test_estimate_LoadFactors_invalid(t *testing.T) {
+	calibrationFilePath := filepath.Join(t.TempDir(), "factors.csv")
+	os.WriteFile(calibrationFilePath, []byte("language,lines\ngolang,10\n"), 0644)
+
+	_, err := LoadFactors(calibrationFilePath)
+	// Assert
+	assert.Error(t, err)
+	_, err = LoadFactors(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
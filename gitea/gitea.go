@@ -0,0 +1,188 @@
+package gitea
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-cloc/devops"
+	"go-cloc/httpclient"
+	"go-cloc/logger"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// apiClient is shared by all requests to the Gitea API so the requests per second are capped
+var apiClient = httpclient.New("Gitea", 10)
+
+// Define a struct with only the fields you care about
+type item struct {
+	Name          string    `json:"name"`
+	DefaultBranch string    `json:"default_branch"`
+	Archived      bool      `json:"archived"`
+	Fork          bool      `json:"fork"`
+	Mirror        bool      `json:"mirror"`
+	Template      bool      `json:"template"`
+	Empty         bool      `json:"empty"`
+	Private       bool      `json:"private"`
+	Internal      bool      `json:"internal"`
+	Size          int64     `json:"size"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Topics        []string  `json:"topics"`
+}
+
+// visibility converts the private and internal flags into a single visibility
+func (i item) visibility() string {
+	if i.Internal {
+		return "internal"
+	}
+	if i.Private {
+		return "private"
+	}
+	return "public"
+}
+
+// trimBaseURL removes any trailing slashes so paths can be appended safely
+func trimBaseURL(baseURL string) string {
+	return strings.TrimRight(baseURL, "/")
+}
+
+func CreateCloneURLGitea(baseURL string, organization string, repoName string) string {
+	return trimBaseURL(baseURL) + "/" + organization + "/" + repoName + ".git"
+}
+
+// CreateCloneAuthGitea creates the credentials for cloning over HTTPS.
+// Gitea and Forgejo accept any username as long as the token is used as the password
+func CreateCloneAuthGitea(accessToken string) transport.AuthMethod {
+	return &githttp.BasicAuth{Username: "oauth2", Password: accessToken}
+}
+
+// CreateSSHCloneURLGitea creates an SSH URL using the host of the base URL, assuming SSH is served on the default port
+func CreateSSHCloneURLGitea(baseURL string, organization string, repoName string) string {
+	u, err := url.Parse(trimBaseURL(baseURL))
+	if err != nil {
+		logger.LogStackTraceAndExit(err)
+	}
+	return "git@" + u.Hostname() + ":" + organization + "/" + repoName + ".git"
+}
+
+func CreateZipURLGitea(baseURL string, organization string, repoName string, defaultBranch string) string {
+	return trimBaseURL(baseURL) + "/api/v1/repos/" + organization + "/" + repoName + "/archive/" + defaultBranch + ".zip"
+}
+
+func CreateDiscoverURLGitea(baseURL string, organization string, pageNum int, pageSize int) string {
+	return trimBaseURL(baseURL) + "/api/v1/orgs/" + organization + "/repos?limit=" + strconv.Itoa(pageSize) + "&page=" + strconv.Itoa(pageNum)
+}
+
+func DiscoverReposGitea(baseURL string, organization string, accessToken string) []devops.RepoInfo {
+	// Gitea caps the page size at 50 by default (MAX_RESPONSE_ITEMS)
+	pageSize := 50
+	pageNum := 1
+	repoNames := []devops.RepoInfo{}
+
+	// pageNum -1 means there are no more pages to discover
+	for pageNum != -1 {
+		apiURL := CreateDiscoverURLGitea(baseURL, organization, pageNum, pageSize)
+		logger.Debug("GET: " + apiURL)
+
+		// Create a new HTTP request
+		req, _ := http.NewRequest("GET", apiURL, nil)
+		req.Header.Set("Authorization", "token "+accessToken)
+
+		// Perform the request using the rate limited client
+		resp, err := apiClient.Do(req)
+		if err != nil {
+			logger.LogStackTraceAndExit(err)
+		}
+		defer resp.Body.Close()
+
+		// Read the response body
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			logger.Error("Failed to read response body: ", body)
+			logger.LogStackTraceAndExit(err)
+		}
+
+		// Check if the status code is 200
+		if resp.StatusCode != http.StatusOK {
+			logger.Error("Response status code: ", resp.StatusCode, " expected 200")
+			logger.LogStackTraceAndExit(nil)
+		}
+
+		// Parse the JSON response into a slice of Item
+		var result []item
+		if err := json.Unmarshal(body, &result); err != nil {
+			logger.Error("Failed to parse JSON: ", err)
+			logger.LogStackTraceAndExit(err)
+		}
+
+		for _, item := range result {
+			repoInfo := devops.NewRepoInfo(organization, "", item.Name, item.DefaultBranch)
+			repoInfo.Archived = item.Archived
+			repoInfo.Fork = item.Fork
+			repoInfo.Mirror = item.Mirror
+			repoInfo.Template = item.Template
+			repoInfo.Empty = item.Empty
+			repoInfo.Visibility = item.visibility()
+			// Gitea reports the size in kilobytes
+			repoInfo.SizeKB = item.Size
+			repoInfo.LastPushed = item.UpdatedAt
+			repoInfo.Topics = item.Topics
+			repoNames = append(repoNames, repoInfo)
+		}
+
+		// Get the next page URL
+		link := resp.Header.Get("Link")
+		logger.Debug("Link header: ", link)
+		// If there is no next page, stop the loop. An empty page also means we are done
+		if len(result) == 0 || link == "" || !strings.Contains(link, `rel="next"`) {
+			pageNum = -1
+		} else {
+			pageNum = pageNum + 1
+		}
+	}
+
+	return repoNames
+}
+
+func CreateLanguagesURLGitea(baseURL string, organization string, repoName string) string {
+	return trimBaseURL(baseURL) + "/api/v1/repos/" + organization + "/" + repoName + "/languages"
+}
+
+// DiscoverLanguagesGitea returns the bytes of code per language on the default branch, as detected by Gitea
+func DiscoverLanguagesGitea(baseURL string, organization string, repoName string, accessToken string) (map[string]int64, error) {
+	apiURL := CreateLanguagesURLGitea(baseURL, organization, repoName)
+	logger.Debug("GET: " + apiURL)
+
+	// Create a new HTTP request
+	req, _ := http.NewRequest("GET", apiURL, nil)
+	req.Header.Set("Authorization", "token "+accessToken)
+
+	// Perform the request using the rate limited client
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status code: %d expected 200", resp.StatusCode)
+	}
+
+	// Gitea reports the languages as {"Go": 12345, ...}
+	languageBytes := map[string]int64{}
+	if err := json.Unmarshal(body, &languageBytes); err != nil {
+		return nil, err
+	}
+	return languageBytes, nil
+}
@@ -0,0 +1,37 @@
+package gitea
+
+import (
+	"testing"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_gitea_CreateCloneURLGitea(t *testing.T) {
+	baseURL := "https://git.example.com/"
+	organization := "organization"
+	repository := "repository"
+
+	cloneUrl := CreateCloneURLGitea(baseURL, organization, repository)
+	// Assert
+	assert.Equal(t, "https://git.example.com/organization/repository.git", cloneUrl)
+	assert.Equal(t, &githttp.BasicAuth{Username: "oauth2", Password: "accesstoken"}, CreateCloneAuthGitea("accesstoken"))
+}
+
+func Test_gitea_CreateDiscoverURLGitea(t *testing.T) {
+	apiURL := CreateDiscoverURLGitea("https://codeberg.org", "organization", 2, 50)
+	// Assert
+	assert.Equal(t, "https://codeberg.org/api/v1/orgs/organization/repos?limit=50&page=2", apiURL)
+}
+
+func Test_gitea_CreateZipURLGitea(t *testing.T) {
+	zipUrl := CreateZipURLGitea("https://codeberg.org", "organization", "repository", "main")
+	// Assert
+	assert.Equal(t, "https://codeberg.org/api/v1/repos/organization/repository/archive/main.zip", zipUrl)
+}
+
+func Test_gitea_CreateSSHCloneURLGitea(t *testing.T) {
+	cloneUrl := CreateSSHCloneURLGitea("https://git.example.com:3000/", "organization", "repository")
+	// Assert
+	assert.Equal(t, "git@git.example.com:organization/repository.git", cloneUrl)
+}
@@ -0,0 +1,249 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"go-cloc/devops"
+	"go-cloc/logger"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Installation tokens are valid for one hour, they are refreshed once they get close to expiring
+const appTokenRefreshMargin = 5 * time.Minute
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type installation struct {
+	Id int64 `json:"id"`
+}
+
+type installationRepositories struct {
+	Repositories []item `json:"repositories"`
+}
+
+// AppTokenSource authenticates as a GitHub App installation, refreshing the installation token before it expires
+type AppTokenSource struct {
+	AppId          string
+	InstallationId string
+	privateKey     *rsa.PrivateKey
+
+	mutex     sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func CreateInstallationTokenURLGithub(installationId string) string {
+	return "https://api.github.com/app/installations/" + installationId + "/access_tokens"
+}
+
+func CreateGetInstallationURLGithub(accountName string, isUser bool) string {
+	if isUser {
+		return "https://api.github.com/users/" + accountName + "/installation"
+	}
+	return "https://api.github.com/orgs/" + accountName + "/installation"
+}
+
+func CreateDiscoverURLGitHubInstallation(pageNum int, pageSize int) string {
+	return "https://api.github.com/installation/repositories?per_page=" + strconv.Itoa(pageSize) + "&page=" + strconv.Itoa(pageNum)
+}
+
+// ParsePrivateKey parses the PEM encoded private key downloaded from the GitHub App settings
+func ParsePrivateKey(pemData []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+	// GitHub provides PKCS#1 keys, PKCS#8 is supported for keys that were converted
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+	key, ok := parsedKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// CreateAppJWT creates the RS256 signed JWT used to authenticate as the GitHub App itself
+func CreateAppJWT(appId string, privateKey *rsa.PrivateKey, now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	// issued in the past to allow for clock drift, GitHub allows a maximum lifetime of 10 minutes
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": appId,
+	})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// sendAppRequest sends a request authenticated with the App JWT and parses the JSON response
+func (source *AppTokenSource) sendAppRequest(method string, url string, expectedStatusCode int, result interface{}) error {
+	jwt, err := CreateAppJWT(source.AppId, source.privateKey, time.Now())
+	if err != nil {
+		return err
+	}
+	logger.Debug(method, ": ", url)
+
+	// Create a new HTTP request
+	req, _ := http.NewRequest(method, url, nil)
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	// Perform the request using the rate limited client
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != expectedStatusCode {
+		return fmt.Errorf("response status code: %d expected %d", resp.StatusCode, expectedStatusCode)
+	}
+	return json.Unmarshal(body, result)
+}
+
+// NewAppTokenSource creates a token source for a GitHub App.
+// If installationId is empty, the installation is looked up for the given account
+func NewAppTokenSource(appId string, installationId string, privateKeyPath string, accountName string) (*AppTokenSource, error) {
+	pemData, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := ParsePrivateKey(pemData)
+	if err != nil {
+		return nil, err
+	}
+
+	source := &AppTokenSource{AppId: appId, InstallationId: installationId, privateKey: privateKey}
+	if source.InstallationId == "" {
+		if accountName == "" {
+			return nil, errors.New("an installation id or an organization is required to authenticate as a GitHub App")
+		}
+		// try the organization first, then a personal account
+		var result installation
+		err := source.sendAppRequest("GET", CreateGetInstallationURLGithub(accountName, false), http.StatusOK, &result)
+		if err != nil {
+			err = source.sendAppRequest("GET", CreateGetInstallationURLGithub(accountName, true), http.StatusOK, &result)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the installation of app %s for %s: %w", appId, accountName, err)
+		}
+		source.InstallationId = strconv.FormatInt(result.Id, 10)
+		logger.Debug("Found installation ", source.InstallationId, " for ", accountName)
+	}
+	return source, nil
+}
+
+// Token returns a valid installation token, exchanging the App JWT for a new one when the current token is about to expire
+func (source *AppTokenSource) Token() string {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if source.token != "" && time.Until(source.expiresAt) > appTokenRefreshMargin {
+		return source.token
+	}
+
+	logger.Debug("Requesting a new installation token for installation ", source.InstallationId)
+	var result installationToken
+	err := source.sendAppRequest("POST", CreateInstallationTokenURLGithub(source.InstallationId), http.StatusCreated, &result)
+	if err != nil {
+		logger.Error("Failed to create an installation token for the GitHub App")
+		logger.LogStackTraceAndExit(err)
+	}
+	logger.RegisterSecret(result.Token)
+	source.token = result.Token
+	source.expiresAt = result.ExpiresAt
+	logger.Debug("Installation token expires at ", source.expiresAt)
+	return source.token
+}
+
+// DiscoverReposGithubApp discovers every repository the GitHub App installation has access to
+func DiscoverReposGithubApp(accessToken string) []devops.RepoInfo {
+	pageSize := 100
+	pageNum := 1
+	repoNames := []devops.RepoInfo{}
+
+	// pageNum -1 means there are no more pages to discover
+	for pageNum != -1 {
+		apiURL := CreateDiscoverURLGitHubInstallation(pageNum, pageSize)
+		logger.Debug("GET: " + apiURL)
+
+		// Create a new HTTP request
+		req, _ := http.NewRequest("GET", apiURL, nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		// Perform the request using the rate limited client
+		resp, err := apiClient.Do(req)
+		if err != nil {
+			logger.LogStackTraceAndExit(err)
+		}
+		defer resp.Body.Close()
+
+		// Read the response body
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			logger.Error("Failed to read response body: ", body)
+			logger.LogStackTraceAndExit(err)
+		}
+
+		// Check if the status code is 200
+		if resp.StatusCode != http.StatusOK {
+			logger.Error("Response status code: ", resp.StatusCode, " expected 200")
+			logger.LogStackTraceAndExit(nil)
+		}
+
+		var result installationRepositories
+		if err := json.Unmarshal(body, &result); err != nil {
+			logger.Error("Failed to parse JSON: ", err)
+			logger.LogStackTraceAndExit(err)
+		}
+
+		for _, item := range result.Repositories {
+			repoNames = append(repoNames, convertItemIntoRepoInfo(item.Owner.Login, item))
+		}
+
+		// If there is no next page, stop the loop
+		link := resp.Header.Get("Link")
+		logger.Debug("Link header: ", link)
+		if link == "" || !strings.Contains(link, `rel="next"`) {
+			pageNum = -1
+		} else {
+			pageNum = pageNum + 1
+		}
+	}
+
+	return repoNames
+}
@@ -0,0 +1,66 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_github_ParsePrivateKey(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(privateKey)
+
+	for _, block := range []*pem.Block{
+		{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)},
+		{Type: "PRIVATE KEY", Bytes: pkcs8},
+	} {
+		parsedKey, err := ParsePrivateKey(pem.EncodeToMemory(block))
+		// Assert
+		assert.NoError(t, err)
+		assert.True(t, privateKey.Equal(parsedKey))
+	}
+
+	_, err := ParsePrivateKey([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func Test_github_CreateAppJWT(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	now := time.Unix(1700000000, 0)
+
+	jwt, err := CreateAppJWT("12345", privateKey, now)
+	assert.NoError(t, err)
+	parts := strings.Split(jwt, ".")
+	assert.Equal(t, 3, len(parts))
+
+	// Assert the claims
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]interface{}
+	json.Unmarshal(claimsJSON, &claims)
+	assert.Equal(t, "12345", claims["iss"])
+	assert.Equal(t, float64(1700000000-60), claims["iat"])
+	assert.Equal(t, float64(1700000000+540), claims["exp"])
+
+	// Assert the signature
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	assert.NoError(t, rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, hash[:], signature))
+}
+
+func Test_github_CreateCloneAuthGithub(t *testing.T) {
+	// Assert
+	assert.Equal(t, "https://github.com/organization/repository.git", CreateCloneURLGithub("organization", "repository"))
+	assert.Equal(t, &githttp.BasicAuth{Username: "oauth2", Password: "ghp_token"}, CreateCloneAuthGithub("ghp_token"))
+	assert.Equal(t, &githttp.BasicAuth{Username: "x-access-token", Password: "ghs_token"}, CreateCloneAuthGithub("ghs_token"))
+}
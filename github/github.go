@@ -2,30 +2,83 @@ package github
 
 import (
 	"encoding/json"
+	"fmt"
 	"go-cloc/devops"
+	"go-cloc/httpclient"
 	"go-cloc/logger"
 	"io"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
+// apiClient is shared by all requests to the GitHub API so the requests per second are capped. GitHub allows 5,000 requests per hour and throttles bursts using secondary rate limits
+var apiClient = httpclient.New("GitHub", 10)
+
 // Define a struct with only the fields you care about
 type item struct {
-	Name          string `json:"name"`
-	DefaultBranch string `json:"default_branch"`
+	Name          string    `json:"name"`
+	DefaultBranch string    `json:"default_branch"`
+	Archived      bool      `json:"archived"`
+	Fork          bool      `json:"fork"`
+	MirrorURL     *string   `json:"mirror_url"`
+	IsTemplate    bool      `json:"is_template"`
+	Visibility    string    `json:"visibility"`
+	Size          int64     `json:"size"`
+	PushedAt      time.Time `json:"pushed_at"`
+	Topics        []string  `json:"topics"`
+	Language      string    `json:"language"`
+	Owner         owner     `json:"owner"`
+}
+
+type owner struct {
+	Login string `json:"login"`
 }
 
 type repo struct {
 	DefaultBranch string `json:"default_branch"`
 }
 
-func CreateCloneURLGithub(accessToken string, organization string, repoName string) string {
-	return "https://oauth2:" + accessToken + "@github.com/" + organization + "/" + repoName + ".git"
+type account struct {
+	// Type is either "User" or "Organization"
+	Type string `json:"type"`
 }
 
-func CreateZipURLGithub(organization string, repoName string, defaultBranch string) string {
-	return "https://github.com/" + organization + "/" + repoName + "/archive/refs/heads/" + defaultBranch + ".zip"
+// IsInstallationTokenGithub checks if the token is a GitHub App installation token rather than a personal access token
+func IsInstallationTokenGithub(accessToken string) bool {
+	return strings.HasPrefix(accessToken, "ghs_")
+}
+
+func CreateCloneURLGithub(organization string, repoName string) string {
+	return "https://github.com/" + organization + "/" + repoName + ".git"
+}
+
+// CreateCloneAuthGithub creates the credentials for cloning over HTTPS, so the token is never part of the URL
+func CreateCloneAuthGithub(accessToken string) transport.AuthMethod {
+	// installation tokens must be used with the x-access-token user
+	username := "oauth2"
+	if IsInstallationTokenGithub(accessToken) {
+		username = "x-access-token"
+	}
+	return &githttp.BasicAuth{Username: username, Password: accessToken}
+}
+
+func CreateSSHCloneURLGithub(organization string, repoName string) string {
+	return "git@github.com:" + organization + "/" + repoName + ".git"
+}
+
+// CreateZipURLGithub creates the archive URL of a branch, a tag or a full reference name (refs/...)
+func CreateZipURLGithub(organization string, repoName string, ref string) string {
+	return "https://github.com/" + organization + "/" + repoName + "/archive/" + ref + ".zip"
+}
+
+// CreateZipURLGithubAPI creates the REST API archive URL, which unlike the github.com archive URL accepts installation tokens
+func CreateZipURLGithubAPI(organization string, repoName string, ref string) string {
+	return "https://api.github.com/repos/" + organization + "/" + repoName + "/zipball/" + ref
 }
 
 func CreateGetDefaultBranchURLGitHub(organization string, repoName string) string {
@@ -36,23 +89,92 @@ func CreateDiscoverURLGitHub(organization string, pageNum int, pageSize int) str
 	return "https://api.github.com/orgs/" + organization + "/repos?per_page=" + strconv.Itoa(pageSize) + "&page=" + strconv.Itoa(pageNum)
 }
 
+func CreateDiscoverURLGitHubUser(user string, pageNum int, pageSize int) string {
+	return "https://api.github.com/users/" + user + "/repos?type=owner&per_page=" + strconv.Itoa(pageSize) + "&page=" + strconv.Itoa(pageNum)
+}
+
+func CreateGetAccountURLGitHub(accountName string) string {
+	return "https://api.github.com/users/" + accountName
+}
+
+// IsUserAccountGithub checks if the account is a personal user account rather than an organization
+func IsUserAccountGithub(accountName string, accessToken string) bool {
+	url := CreateGetAccountURLGitHub(accountName)
+	logger.Debug("GET: " + url)
+
+	// Create a new HTTP request
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	// Perform the request using the rate limited client
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		logger.LogStackTraceAndExit(err)
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logger.Error("Failed to read response body: ", body)
+		logger.LogStackTraceAndExit(err)
+	}
+
+	// Check if the status code is 200
+	if resp.StatusCode != http.StatusOK {
+		logger.Error("Account ", accountName, " could not be found. Response status code: ", resp.StatusCode, " expected 200")
+		logger.LogStackTraceAndExit(nil)
+	}
+
+	var accountResult account
+	if err := json.Unmarshal(body, &accountResult); err != nil {
+		logger.Error("Failed to parse JSON: ", err)
+		logger.LogStackTraceAndExit(err)
+	}
+
+	logger.Debug("Account ", accountName, " is of type ", accountResult.Type)
+	return accountResult.Type == "User"
+}
+
+func convertItemIntoRepoInfo(organization string, item item) devops.RepoInfo {
+	repoInfo := devops.NewRepoInfo(organization, "", item.Name, item.DefaultBranch)
+	repoInfo.Archived = item.Archived
+	repoInfo.Fork = item.Fork
+	repoInfo.Mirror = item.MirrorURL != nil
+	repoInfo.Template = item.IsTemplate
+	repoInfo.Visibility = item.Visibility
+	// GitHub reports the size in kilobytes
+	repoInfo.SizeKB = item.Size
+	repoInfo.Empty = item.Size == 0
+	repoInfo.LastPushed = item.PushedAt
+	repoInfo.Topics = item.Topics
+	repoInfo.PrimaryLanguage = item.Language
+	return repoInfo
+}
+
+// DiscoverReposGithub discovers all repositories owned by an organization or a personal user account
 func DiscoverReposGithub(organization string, accessToken string) []devops.RepoInfo {
 	pageSize := 100
 	pageNum := 1
 	repoNames := []devops.RepoInfo{}
 
+	createDiscoverURL := CreateDiscoverURLGitHub
+	if IsUserAccountGithub(organization, accessToken) {
+		logger.Info(organization, " is a user account, discovering repositories owned by the user")
+		createDiscoverURL = CreateDiscoverURLGitHubUser
+	}
+
 	// pageNum -1 means there are no more pages to discover
 	for pageNum != -1 {
-		apiURL := CreateDiscoverURLGitHub(organization, pageNum, pageSize)
+		apiURL := createDiscoverURL(organization, pageNum, pageSize)
 		logger.Debug("GET: " + apiURL)
 
 		// Create a new HTTP request
 		req, _ := http.NewRequest("GET", apiURL, nil)
 		req.Header.Set("Authorization", "Bearer "+accessToken)
 
-		// Perform the request using the default HTTP client
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		// Perform the request using the rate limited client
+		resp, err := apiClient.Do(req)
 		if err != nil {
 			logger.LogStackTraceAndExit(err)
 		}
@@ -82,8 +204,7 @@ func DiscoverReposGithub(organization string, accessToken string) []devops.RepoI
 		logger.Debug("Default branch is: ", result)
 
 		for _, item := range result {
-			repoInfo := devops.NewRepoInfo(organization, "", item.Name, item.DefaultBranch)
-			repoNames = append(repoNames, repoInfo)
+			repoNames = append(repoNames, convertItemIntoRepoInfo(organization, item))
 		}
 
 		// Get the next page URL
@@ -110,9 +231,8 @@ func DiscoverDefaultBranchForRepoGithub(organization string, repoName string, ac
 	req, _ := http.NewRequest("GET", url, nil)
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	// Perform the request using the default HTTP client
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Perform the request using the rate limited client
+	resp, err := apiClient.Do(req)
 	if err != nil {
 		logger.LogStackTraceAndExit(err)
 	}
@@ -143,3 +263,40 @@ func DiscoverDefaultBranchForRepoGithub(organization string, repoName string, ac
 
 	return repoResult.DefaultBranch
 }
+
+func CreateLanguagesURLGithub(organization string, repoName string) string {
+	return "https://api.github.com/repos/" + organization + "/" + repoName + "/languages"
+}
+
+// DiscoverLanguagesGithub returns the bytes of code per language on the default branch, as detected by GitHub
+func DiscoverLanguagesGithub(organization string, repoName string, accessToken string) (map[string]int64, error) {
+	url := CreateLanguagesURLGithub(organization, repoName)
+	logger.Debug("GET: " + url)
+
+	// Create a new HTTP request
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	// Perform the request using the rate limited client
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status code: %d expected 200", resp.StatusCode)
+	}
+
+	// GitHub reports the languages as {"Go": 12345, ...}
+	languageBytes := map[string]int64{}
+	if err := json.Unmarshal(body, &languageBytes); err != nil {
+		return nil, err
+	}
+	return languageBytes, nil
+}
@@ -0,0 +1,15 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_github_CreateZipURLGithub(t *testing.T) {
+	// Assert
+	assert.Equal(t, "https://github.com/organization/repository/archive/main.zip", CreateZipURLGithub("organization", "repository", "main"))
+	assert.Equal(t, "https://github.com/organization/repository/archive/v1.2.0.zip", CreateZipURLGithub("organization", "repository", "v1.2.0"))
+	assert.Equal(t, "https://github.com/organization/repository/archive/refs/tags/v1.2.0.zip", CreateZipURLGithub("organization", "repository", "refs/tags/v1.2.0"))
+	assert.Equal(t, "https://api.github.com/repos/organization/repository/zipball/v1.2.0", CreateZipURLGithubAPI("organization", "repository", "v1.2.0"))
+}
@@ -0,0 +1,206 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-cloc/devops"
+	"go-cloc/logger"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Discovery backends
+const (
+	GRAPHQL string = "graphql"
+	REST    string = "rest"
+)
+
+// Repositories owned by a user or organization, including everything needed to filter and clone them in one pass.
+// repositoryOwner resolves both users and organizations, so no extra request is needed to find the account type
+const discoverQueryGithub = `query($login: String!, $pageSize: Int!, $cursor: String) {
+  repositoryOwner(login: $login) {
+    repositories(first: $pageSize, after: $cursor, ownerAffiliations: OWNER) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        name
+        defaultBranchRef { name }
+        diskUsage
+        isArchived
+        isFork
+        isMirror
+        isTemplate
+        isEmpty
+        visibility
+        pushedAt
+        primaryLanguage { name }
+        repositoryTopics(first: 100) { nodes { topic { name } } }
+      }
+    }
+  }
+}`
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLNode struct {
+	Name             string `json:"name"`
+	DefaultBranchRef *struct {
+		Name string `json:"name"`
+	} `json:"defaultBranchRef"`
+	DiskUsage       int64     `json:"diskUsage"`
+	IsArchived      bool      `json:"isArchived"`
+	IsFork          bool      `json:"isFork"`
+	IsMirror        bool      `json:"isMirror"`
+	IsTemplate      bool      `json:"isTemplate"`
+	IsEmpty         bool      `json:"isEmpty"`
+	Visibility      string    `json:"visibility"`
+	PushedAt        time.Time `json:"pushedAt"`
+	PrimaryLanguage *struct {
+		Name string `json:"name"`
+	} `json:"primaryLanguage"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+}
+
+type graphQLResponse struct {
+	Data struct {
+		RepositoryOwner *struct {
+			Repositories struct {
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+				Nodes []graphQLNode `json:"nodes"`
+			} `json:"repositories"`
+		} `json:"repositoryOwner"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+func CreateGraphQLURLGithub() string {
+	return "https://api.github.com/graphql"
+}
+
+// convertGraphQLNodeIntoRepoInfo converts a repository from the GraphQL API into a RepoInfo
+func convertGraphQLNodeIntoRepoInfo(organization string, node graphQLNode) devops.RepoInfo {
+	defaultBranch := ""
+	// empty repositories do not have a default branch
+	if node.DefaultBranchRef != nil {
+		defaultBranch = node.DefaultBranchRef.Name
+	}
+	repoInfo := devops.NewRepoInfo(organization, "", node.Name, defaultBranch)
+	repoInfo.Archived = node.IsArchived
+	repoInfo.Fork = node.IsFork
+	repoInfo.Mirror = node.IsMirror
+	repoInfo.Template = node.IsTemplate
+	repoInfo.Empty = node.IsEmpty
+	// GraphQL reports PUBLIC, PRIVATE or INTERNAL
+	repoInfo.Visibility = strings.ToLower(node.Visibility)
+	// diskUsage is reported in kilobytes
+	repoInfo.SizeKB = node.DiskUsage
+	repoInfo.LastPushed = node.PushedAt
+	if node.PrimaryLanguage != nil {
+		repoInfo.PrimaryLanguage = node.PrimaryLanguage.Name
+	}
+	for _, topicNode := range node.RepositoryTopics.Nodes {
+		repoInfo.Topics = append(repoInfo.Topics, topicNode.Topic.Name)
+	}
+	return repoInfo
+}
+
+// sendGraphQLRequest sends a single page of the discover query
+func sendGraphQLRequest(organization string, accessToken string, pageSize int, cursor string) (graphQLResponse, error) {
+	var result graphQLResponse
+	variables := map[string]interface{}{"login": organization, "pageSize": pageSize}
+	if cursor != "" {
+		variables["cursor"] = cursor
+	}
+	requestBody, _ := json.Marshal(graphQLRequest{Query: discoverQueryGithub, Variables: variables})
+
+	apiURL := CreateGraphQLURLGithub()
+	logger.Debug("POST: ", apiURL, " cursor: ", cursor)
+
+	// Create a new HTTP request
+	req, _ := http.NewRequest("POST", apiURL, bytes.NewReader(requestBody))
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	// Perform the request using the rate limited client
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("response status code: %d expected 200", resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, err
+	}
+	// GraphQL reports errors such as insufficient scopes with a 200 status code
+	if len(result.Errors) > 0 {
+		return result, fmt.Errorf("GraphQL error: %s", result.Errors[0].Message)
+	}
+	if result.Data.RepositoryOwner == nil {
+		return result, fmt.Errorf("account %s could not be found", organization)
+	}
+	return result, nil
+}
+
+// DiscoverReposGithubGraphQL discovers all repositories owned by an organization or a personal user account,
+// including the default branch, size and primary language, using pages of 100 repositories
+func DiscoverReposGithubGraphQL(organization string, accessToken string) ([]devops.RepoInfo, error) {
+	pageSize := 100
+	cursor := ""
+	repoNames := []devops.RepoInfo{}
+
+	for {
+		result, err := sendGraphQLRequest(organization, accessToken, pageSize, cursor)
+		if err != nil {
+			return nil, err
+		}
+		repositories := result.Data.RepositoryOwner.Repositories
+		for _, node := range repositories.Nodes {
+			repoNames = append(repoNames, convertGraphQLNodeIntoRepoInfo(organization, node))
+		}
+
+		// If there is no next page, stop the loop
+		if !repositories.PageInfo.HasNextPage {
+			break
+		}
+		cursor = repositories.PageInfo.EndCursor
+	}
+
+	return repoNames, nil
+}
+
+// DiscoverReposGithubWithBackend discovers repositories using GraphQL, falling back to REST if the GraphQL API fails
+func DiscoverReposGithubWithBackend(organization string, accessToken string, backend string) []devops.RepoInfo {
+	if backend == REST {
+		return DiscoverReposGithub(organization, accessToken)
+	}
+	repoInfos, err := DiscoverReposGithubGraphQL(organization, accessToken)
+	if err != nil {
+		logger.Warn("GraphQL discovery failed for ", organization, ", falling back to REST: ", err)
+		return DiscoverReposGithub(organization, accessToken)
+	}
+	return repoInfos
+}
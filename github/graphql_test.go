@@ -0,0 +1,42 @@
+package github
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_github_convertGraphQLNodeIntoRepoInfo(t *testing.T) {
+	responseJSON := `{"data": {"repositoryOwner": {"repositories": {
+		"pageInfo": {"hasNextPage": false, "endCursor": "Y3Vyc29y"},
+		"nodes": [
+			{"name": "service", "defaultBranchRef": {"name": "develop"}, "diskUsage": 2048, "isArchived": true, "isFork": false,
+			 "isMirror": false, "isTemplate": false, "isEmpty": false, "visibility": "INTERNAL", "pushedAt": "2025-03-04T05:06:07Z",
+			 "primaryLanguage": {"name": "Go"}, "repositoryTopics": {"nodes": [{"topic": {"name": "payments"}}]}},
+			{"name": "empty", "defaultBranchRef": null, "diskUsage": 0, "isEmpty": true, "visibility": "PRIVATE", "pushedAt": null,
+			 "primaryLanguage": null, "repositoryTopics": {"nodes": []}}
+		]}}}}`
+	var response graphQLResponse
+	assert.NoError(t, json.Unmarshal([]byte(responseJSON), &response))
+	nodes := response.Data.RepositoryOwner.Repositories.Nodes
+
+	repoInfo := convertGraphQLNodeIntoRepoInfo("organization", nodes[0])
+	// Assert
+	assert.Equal(t, "organization-service", repoInfo.Id)
+	assert.Equal(t, "develop", repoInfo.DefaultBranch)
+	assert.Equal(t, int64(2048), repoInfo.SizeKB)
+	assert.True(t, repoInfo.Archived)
+	assert.Equal(t, "internal", repoInfo.Visibility)
+	assert.Equal(t, time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC), repoInfo.LastPushed)
+	assert.Equal(t, "Go", repoInfo.PrimaryLanguage)
+	assert.Equal(t, []string{"payments"}, repoInfo.Topics)
+
+	repoInfo = convertGraphQLNodeIntoRepoInfo("organization", nodes[1])
+	// Assert
+	assert.Equal(t, "", repoInfo.DefaultBranch)
+	assert.True(t, repoInfo.Empty)
+	assert.Equal(t, "", repoInfo.PrimaryLanguage)
+	assert.Nil(t, repoInfo.Topics)
+}
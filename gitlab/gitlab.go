@@ -2,33 +2,98 @@ package gitlab
 
 import (
 	"encoding/json"
+	"fmt"
 	"go-cloc/devops"
+	"go-cloc/httpclient"
 	"go-cloc/logger"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
 )
 
-func CreateCloneURLGitLab(accessToken string, organization string, respository string) string {
+// apiClient is shared by all requests to the GitLab API so the requests per second are capped
+var apiClient = httpclient.New("GitLab", 10)
+
+/*
+@namespace Full path of the group the project lives in, e.g. organization/subgroup
+@repoPath Path of the project, which can differ from its display name
+*/
+func CreateCloneURLGitLab(namespace string, repoPath string) string {
 	// Create the URL
-	return "https://oauth2:" + accessToken + "@gitlab.com/" + organization + "/" + respository + ".git"
+	return "https://gitlab.com/" + namespace + "/" + repoPath + ".git"
+}
+
+// CreateCloneAuthGitLab creates the credentials for cloning over HTTPS, so the token is never part of the URL
+func CreateCloneAuthGitLab(accessToken string) transport.AuthMethod {
+	return &githttp.BasicAuth{Username: "oauth2", Password: accessToken}
 }
 
-// Discovers projects in a GitLab organization
-func CreateDiscoverURLGitLab(accessToken string, organization string, pageNum int, pageSize int) string {
-	return "https://" + accessToken + "@gitlab.com/api/v4/groups/" + organization + "/projects?per_page=" + strconv.Itoa(pageSize) + "&page=" + strconv.Itoa(pageNum)
+/*
+@namespace Full path of the group the project lives in, e.g. organization/subgroup
+@repoPath Path of the project, which can differ from its display name
+*/
+func CreateSSHCloneURLGitLab(namespace string, repoPath string) string {
+	return "git@gitlab.com:" + namespace + "/" + repoPath + ".git"
 }
 
-func CreateZipURLGitLab(organization string, repoName string, defaultBranch string) string {
-	return "https://gitlab.com/" + organization + "/" + repoName + "/-/archive/" + defaultBranch + "/" + repoName + "-" + defaultBranch + ".zip"
+// Discovers projects in a GitLab organization, including projects in nested subgroups
+func CreateDiscoverURLGitLab(organization string, pageNum int, pageSize int) string {
+	// a subgroup can be used as the organization, its path must be URL encoded
+	return "https://gitlab.com/api/v4/groups/" + url.PathEscape(organization) + "/projects?include_subgroups=true&statistics=true&per_page=" + strconv.Itoa(pageSize) + "&page=" + strconv.Itoa(pageNum)
+}
+
+func CreateZipURLGitLab(namespace string, repoPath string, defaultBranch string) string {
+	return "https://gitlab.com/" + namespace + "/" + repoPath + "/-/archive/" + defaultBranch + "/" + repoPath + "-" + defaultBranch + ".zip"
 }
 
 // Define the nested struct types
 type item struct {
-	Name          string `json:"name"`
-	DefaultBranch string `json:"default_branch"`
+	Name              string      `json:"name"`
+	Path              string      `json:"path"`
+	DefaultBranch     string      `json:"default_branch"`
+	Namespace         namespace   `json:"namespace"`
+	Archived          bool        `json:"archived"`
+	ForkedFromProject *struct{}   `json:"forked_from_project"`
+	Mirror            bool        `json:"mirror"`
+	EmptyRepo         bool        `json:"empty_repo"`
+	Visibility        string      `json:"visibility"`
+	LastActivityAt    time.Time   `json:"last_activity_at"`
+	Statistics        *statistics `json:"statistics"`
+	Topics            []string    `json:"topics"`
+}
+
+// statistics are only returned to members with at least reporter access
+type statistics struct {
+	RepositorySize int64 `json:"repository_size"`
+}
+
+type namespace struct {
+	FullPath string `json:"full_path"`
+}
+
+// CreateRepoInfoGitLab converts a discovered project into a RepoInfo.
+// The subgroup path relative to the organization is used as the project name so totals can be rolled up per subgroup
+func CreateRepoInfoGitLab(organization string, name string, path string, namespaceFullPath string, defaultBranch string) devops.RepoInfo {
+	// GitLab paths are case-insensitive, so --organization may differ in case from the full path
+	subgroup := ""
+	if len(namespaceFullPath) > len(organization) && strings.EqualFold(namespaceFullPath[:len(organization)], organization) && namespaceFullPath[len(organization)] == '/' {
+		subgroup = namespaceFullPath[len(organization)+1:]
+	}
+	repoInfo := devops.NewRepoInfo(organization, subgroup, name, defaultBranch)
+	if namespaceFullPath != "" {
+		repoInfo.Namespace = namespaceFullPath
+	}
+	if path != "" {
+		repoInfo.Slug = path
+	}
+	return repoInfo
 }
 
 func DiscoverReposGitlab(organization string, accessToken string) []devops.RepoInfo {
@@ -37,7 +102,7 @@ func DiscoverReposGitlab(organization string, accessToken string) []devops.RepoI
 	repoNames := []devops.RepoInfo{}
 	// pageNum -1 means there are no more pages to discover
 	for pageNum != -1 {
-		apiURL := CreateDiscoverURLGitLab(accessToken, organization, pageNum, pageSize)
+		apiURL := CreateDiscoverURLGitLab(organization, pageNum, pageSize)
 		logger.Debug("Discovering repos using url: ", apiURL)
 
 		// Create a new HTTP request
@@ -49,9 +114,8 @@ func DiscoverReposGitlab(organization string, accessToken string) []devops.RepoI
 		// Add the Authorization header
 		req.Header.Set("Authorization", "Bearer "+accessToken)
 
-		// Perform the request using the default HTTP client
-		client := &http.Client{}
-		resp, err := client.Do(req)
+		// Perform the request using the rate limited client
+		resp, err := apiClient.Do(req)
 		if err != nil {
 			log.Fatalf("Failed to fetch data from API: %v", err)
 		}
@@ -76,7 +140,19 @@ func DiscoverReposGitlab(organization string, accessToken string) []devops.RepoI
 
 		// Print the parsed data
 		for _, item := range result {
-			repoInfo := devops.NewRepoInfo(organization, "", item.Name, item.DefaultBranch)
+			repoInfo := CreateRepoInfoGitLab(organization, item.Name, item.Path, item.Namespace.FullPath, item.DefaultBranch)
+			repoInfo.Archived = item.Archived
+			repoInfo.Fork = item.ForkedFromProject != nil
+			repoInfo.Mirror = item.Mirror
+			repoInfo.Empty = item.EmptyRepo
+			repoInfo.Visibility = item.Visibility
+			repoInfo.LastPushed = item.LastActivityAt
+			repoInfo.Topics = item.Topics
+			if item.Statistics != nil {
+				// GitLab reports the size in bytes
+				repoInfo.SizeKB = item.Statistics.RepositorySize / 1024
+			}
+			logger.Debug("Discovered ", repoInfo.Namespace, "/", repoInfo.Slug)
 			repoNames = append(repoNames, repoInfo)
 		}
 
@@ -94,3 +170,45 @@ func DiscoverReposGitlab(organization string, accessToken string) []devops.RepoI
 
 	return repoNames
 }
+
+/*
+@namespace Full path of the group the project lives in, e.g. organization/subgroup
+@repoPath Path of the project, which can differ from its display name
+*/
+func CreateLanguagesURLGitLab(namespace string, repoPath string) string {
+	// the full path of the project is used as its id, it must be URL encoded
+	return "https://gitlab.com/api/v4/projects/" + url.PathEscape(namespace+"/"+repoPath) + "/languages"
+}
+
+// DiscoverLanguagesGitLab returns the percentage of each language on the default branch, as detected by GitLab
+func DiscoverLanguagesGitLab(namespace string, repoPath string, accessToken string) (map[string]float64, error) {
+	apiURL := CreateLanguagesURLGitLab(namespace, repoPath)
+	logger.Debug("GET: " + apiURL)
+
+	// Create a new HTTP request
+	req, _ := http.NewRequest("GET", apiURL, nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	// Perform the request using the rate limited client
+	resp, err := apiClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("response status code: %d expected 200", resp.StatusCode)
+	}
+
+	// GitLab reports the languages as {"Go": 66.69, ...}
+	percentages := map[string]float64{}
+	if err := json.Unmarshal(body, &percentages); err != nil {
+		return nil, err
+	}
+	return percentages, nil
+}
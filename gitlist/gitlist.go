@@ -0,0 +1,86 @@
+package gitlist
+
+import (
+	"go-cloc/devops"
+	"go-cloc/logger"
+	"os"
+	"strings"
+)
+
+// Placeholder that can be used in the ref column to keep the default branch while still providing a name
+const defaultRefPlaceholder = "-"
+
+// ParseRepoNameFromURL returns the organization and repository name for a git URL.
+// Supports https://host/org/repo.git, ssh://git@host/org/repo.git and git@host:org/repo.git.
+// The organization is empty when the path has a single segment, e.g. https://host/repo.git
+func ParseRepoNameFromURL(gitURL string) (string, string) {
+	trimmed := strings.TrimSuffix(strings.TrimRight(gitURL, "/"), ".git")
+	path := trimmed
+	if index := strings.Index(trimmed, "://"); index != -1 {
+		// drop the scheme and the host, which may contain a port, e.g. https://host:8443/
+		path = trimmed[index+3:]
+		if index := strings.Index(path, "/"); index != -1 {
+			path = path[index+1:]
+		} else {
+			path = ""
+		}
+	} else if index := strings.Index(trimmed, ":"); index != -1 {
+		// scp-like syntax uses a colon between the host and the path, e.g. git@host:org/repo
+		path = trimmed[index+1:]
+	}
+
+	parts := strings.Split(path, "/")
+	repoName := parts[len(parts)-1]
+	organization := ""
+	if len(parts) > 1 {
+		organization = parts[len(parts)-2]
+	}
+	return organization, repoName
+}
+
+// ParseRepoLine converts a single line of a repositories file into a RepoInfo.
+// A line has the format: <url> [ref] [name]
+func ParseRepoLine(line string) devops.RepoInfo {
+	fields := strings.Fields(line)
+	gitURL := fields[0]
+	organization, repoName := ParseRepoNameFromURL(gitURL)
+
+	ref := ""
+	if len(fields) > 1 && fields[1] != defaultRefPlaceholder {
+		ref = fields[1]
+	}
+	if len(fields) > 2 {
+		// the name is used for the clone directory and the results file, so it cannot point elsewhere
+		repoName = devops.FlattenPath(fields[2])
+	}
+
+	// the ref is stored as the default branch, an empty ref clones whatever HEAD points to
+	repoInfo := devops.NewRepoInfo(organization, "", repoName, ref)
+	if organization == "" {
+		repoInfo.Id = devops.FlattenPath(repoName)
+	}
+	repoInfo.CloneURL = gitURL
+	return repoInfo
+}
+
+// DiscoverReposFromFile reads a list of git URLs and builds a RepoInfo for each one without calling any DevOps API.
+// Empty lines and lines starting with # are skipped.
+func DiscoverReposFromFile(path string) []devops.RepoInfo {
+	logger.Debug("Reading repositories file ", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.LogStackTraceAndExit(err)
+	}
+
+	repoInfos := []devops.RepoInfo{}
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		repoInfo := ParseRepoLine(trimmed)
+		logger.Debug("Adding repository ", repoInfo.RepositoryName, " with ref '", repoInfo.DefaultBranch, "'")
+		repoInfos = append(repoInfos, repoInfo)
+	}
+	return repoInfos
+}
@@ -0,0 +1,55 @@
+package gitlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_gitlist_ParseRepoNameFromURL(t *testing.T) {
+	organization, repoName := ParseRepoNameFromURL("https://git.example.com/team/service.git")
+	assert.Equal(t, "team", organization)
+	assert.Equal(t, "service", repoName)
+
+	organization, repoName = ParseRepoNameFromURL("git@git.example.com:team/service.git")
+	assert.Equal(t, "team", organization)
+	assert.Equal(t, "service", repoName)
+
+	organization, repoName = ParseRepoNameFromURL("ssh://git@mirror.example.com/service")
+	assert.Equal(t, "", organization)
+	assert.Equal(t, "service", repoName)
+
+	// the port is part of the host, not the organization
+	organization, repoName = ParseRepoNameFromURL("https://git.example.com:8443/repo")
+	assert.Equal(t, "", organization)
+	assert.Equal(t, "repo", repoName)
+
+	organization, repoName = ParseRepoNameFromURL("ssh://git@git.example.com:2222/group/subgroup/service.git")
+	assert.Equal(t, "subgroup", organization)
+	assert.Equal(t, "service", repoName)
+}
+
+func Test_gitlist_ParseRepoLine(t *testing.T) {
+	repoInfo := ParseRepoLine("git@git.example.com:team/service.git  release/1.0  billing-service")
+
+	// Assert
+	assert.Equal(t, "git@git.example.com:team/service.git", repoInfo.CloneURL)
+	assert.Equal(t, "release/1.0", repoInfo.DefaultBranch)
+	assert.Equal(t, "billing-service", repoInfo.RepositoryName)
+	assert.Equal(t, "team-billing-service", repoInfo.Id)
+
+	repoInfo = ParseRepoLine("https://git.example.com/team/service.git - renamed")
+	assert.Equal(t, "", repoInfo.DefaultBranch)
+	assert.Equal(t, "renamed", repoInfo.RepositoryName)
+
+	// without an organization, the id is the name alone
+	repoInfo = ParseRepoLine("ssh://git@mirror.example.com/service")
+	assert.Equal(t, "service", repoInfo.Id)
+
+	// path separators in the name cannot escape the results directory
+	repoInfo = ParseRepoLine("ssh://git@mirror.example.com/service - ../team/service")
+	assert.Equal(t, "..-team-service", repoInfo.RepositoryName)
+	assert.Equal(t, "..-team-service", repoInfo.Id)
+	repoInfo = ParseRepoLine("https://git.example.com/team/service.git - team\\service")
+	assert.Equal(t, "team-team-service", repoInfo.Id)
+}
@@ -0,0 +1,217 @@
+package httpclient
+
+import (
+	"go-cloc/logger"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum number of times a rate limited request is retried
+const MaxRetries = 5
+
+// Interval at which progress is logged while waiting for a rate limit to reset
+const progressInterval = 30 * time.Second
+
+// Delay before retrying a 429 response without any rate limit headers, doubled on every attempt
+const backoffDelay = 2 * time.Second
+
+// sleep and now are replaced in tests
+var sleep = time.Sleep
+var now = time.Now
+
+// Client is an HTTP client shared by all requests to a single provider. It caps the number of requests per second
+// and waits for rate limits to reset, based on the Retry-After, X-RateLimit-* and RateLimit-* response headers
+type Client struct {
+	Name       string
+	HTTPClient *http.Client
+
+	mutex       sync.Mutex
+	interval    time.Duration
+	nextRequest time.Time
+}
+
+// defaultTransport is set by Configure, nil uses the Go default transport
+var defaultTransport http.RoundTripper
+
+// clients by provider name, so the requests per second can be configured from the CLI
+var clients = map[string]*Client{}
+var clientsMutex sync.Mutex
+
+/*
+@name Name of the provider, e.g. GitHub, used in log messages and to configure the client
+@requestsPerSecond Maximum number of requests per second, 0 for no limit
+*/
+func New(name string, requestsPerSecond float64) *Client {
+	client := &Client{Name: name}
+	client.SetRequestsPerSecond(requestsPerSecond)
+
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+	client.HTTPClient = &http.Client{Transport: defaultTransport}
+	clients[name] = client
+	return client
+}
+
+// SetRequestsPerSecond changes the maximum number of requests per second, 0 for no limit
+func (client *Client) SetRequestsPerSecond(requestsPerSecond float64) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.interval = 0
+	if requestsPerSecond > 0 {
+		client.interval = time.Duration(float64(time.Second) / requestsPerSecond)
+	}
+}
+
+// SetRequestsPerSecond changes the maximum number of requests per second of the client of the provider.
+// Returns false if there is no client for the provider
+func SetRequestsPerSecond(name string, requestsPerSecond float64) bool {
+	clientsMutex.Lock()
+	client, found := clients[name]
+	clientsMutex.Unlock()
+	if found {
+		client.SetRequestsPerSecond(requestsPerSecond)
+	}
+	return found
+}
+
+// parseResetTime parses a reset header which is either a Unix timestamp, or a number of seconds from now
+func parseResetTime(value string, current time.Time) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	// anything before 2001 cannot be a timestamp, so it is a delay
+	if seconds < 1000000000 {
+		return current.Add(time.Duration(seconds) * time.Second), true
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// ResetTime returns when the rate limit resets based on the response headers, false if the headers do not say
+func ResetTime(header http.Header, current time.Time) (time.Time, bool) {
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		// either a number of seconds or an HTTP date
+		if seconds, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil {
+			return current.Add(time.Duration(seconds) * time.Second), true
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return date, true
+		}
+	}
+	for _, name := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if reset, found := parseResetTime(header.Get(name), current); found {
+			return reset, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// isExhausted checks if the response reports that no requests remain until the rate limit resets
+func isExhausted(header http.Header) bool {
+	for _, name := range []string{"X-RateLimit-Remaining", "RateLimit-Remaining"} {
+		if strings.TrimSpace(header.Get(name)) == "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRateLimited checks if the request was rejected because of a rate limit.
+// GitHub uses 403 for both its primary and secondary rate limits
+func IsRateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return resp.StatusCode == http.StatusForbidden && (isExhausted(resp.Header) || resp.Header.Get("Retry-After") != "")
+}
+
+// waitWithProgress sleeps until the given time, logging the remaining time at regular intervals
+func (client *Client) waitWithProgress(until time.Time) {
+	remaining := until.Sub(now())
+	if remaining <= 0 {
+		return
+	}
+	logger.Warn("Rate limit reached for ", client.Name, ", waiting ", remaining.Round(time.Second), " until ", until.Format(time.TimeOnly))
+	for remaining > 0 {
+		sleep(min(remaining, progressInterval))
+		remaining = until.Sub(now())
+		if remaining > 0 {
+			logger.Info("Waiting for the ", client.Name, " rate limit to reset, ", remaining.Round(time.Second), " remaining")
+		}
+	}
+}
+
+// throttle waits until the next request is allowed, based on the requests per second and any exhausted rate limit
+func (client *Client) throttle() {
+	client.mutex.Lock()
+	current := now()
+	start := current
+	if client.nextRequest.After(current) {
+		start = client.nextRequest
+	}
+	client.nextRequest = start.Add(client.interval)
+	client.mutex.Unlock()
+
+	// waits longer than a second are caused by a rate limit rather than the requests per second
+	if wait := start.Sub(current); wait > time.Second {
+		client.waitWithProgress(start)
+	} else if wait > 0 {
+		sleep(wait)
+	}
+}
+
+// pauseUntil delays every following request until the given time
+func (client *Client) pauseUntil(until time.Time) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if until.After(client.nextRequest) {
+		client.nextRequest = until
+	}
+}
+
+// Do sends the request, retrying it when it is rate limited. The request body is recreated using GetBody for retries
+func (client *Client) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		client.throttle()
+		resp, err := client.HTTPClient.Do(req)
+		if err != nil {
+			return resp, err
+		}
+
+		current := now()
+		reset, hasReset := ResetTime(resp.Header, current)
+		if !IsRateLimited(resp) {
+			// the request succeeded, but the following requests would be rejected until the reset
+			if hasReset && isExhausted(resp.Header) {
+				logger.Debug(client.Name, " rate limit exhausted, pausing requests until ", reset)
+				client.pauseUntil(reset)
+			}
+			return resp, nil
+		}
+		if attempt >= MaxRetries {
+			logger.Error("Rate limit for ", client.Name, " did not reset after ", MaxRetries, " retries")
+			return resp, nil
+		}
+
+		// discard the rejected response so the connection can be reused
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if !hasReset || !reset.After(current) {
+			reset = current.Add(backoffDelay << attempt)
+		}
+		logger.Debug(client.Name, " responded with ", resp.Status, ", retrying at ", reset, " (attempt ", attempt+1, "/", MaxRetries, ")")
+		client.pauseUntil(reset)
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock replaces sleeping with advancing the current time
+func fakeClock(t *testing.T) *[]time.Duration {
+	current := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	sleeps := []time.Duration{}
+	now = func() time.Time { return current }
+	sleep = func(duration time.Duration) {
+		sleeps = append(sleeps, duration)
+		current = current.Add(duration)
+	}
+	t.Cleanup(func() {
+		now = time.Now
+		sleep = time.Sleep
+	})
+	return &sleeps
+}
+
+func Test_httpclient_ResetTime(t *testing.T) {
+	current := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	reset, found := ResetTime(http.Header{"Retry-After": {"30"}}, current)
+	assert.True(t, found)
+	assert.Equal(t, current.Add(30*time.Second), reset)
+
+	reset, found = ResetTime(http.Header{"Retry-After": {"Wed, 01 Jan 2025 12:05:00 GMT"}}, current)
+	assert.True(t, found)
+	assert.Equal(t, current.Add(5*time.Minute), reset.UTC())
+
+	reset, found = ResetTime(http.Header{"X-Ratelimit-Reset": {"1735733100"}}, current)
+	assert.True(t, found)
+	assert.Equal(t, current.Add(5*time.Minute), reset.UTC())
+
+	reset, found = ResetTime(http.Header{"Ratelimit-Reset": {"60"}}, current)
+	assert.True(t, found)
+	assert.Equal(t, current.Add(time.Minute), reset)
+
+	_, found = ResetTime(http.Header{}, current)
+	assert.False(t, found)
+}
+
+func Test_httpclient_Do_retries_rate_limited_requests(t *testing.T) {
+	sleeps := fakeClock(t)
+	attempts := 0
+	bodies := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch attempts {
+		case 1:
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			// GitHub reports an exhausted primary rate limit with a 403
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1735732800")
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	client := New("Test", 0)
+	req, _ := http.NewRequest("POST", server.URL, strings.NewReader("query"))
+	resp, err := client.Do(req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, []string{"query", "query", "query"}, bodies)
+	// 120 seconds are waited in steps so the progress is logged, then the backoff since the reset is in the past
+	assert.Equal(t, []time.Duration{30 * time.Second, 30 * time.Second, 30 * time.Second, 30 * time.Second, 4 * time.Second}, *sleeps)
+}
+
+func Test_httpclient_Do_caps_requests_per_second(t *testing.T) {
+	sleeps := fakeClock(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New("Test", 4)
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		_, err := client.Do(req)
+		assert.NoError(t, err)
+	}
+
+	// Assert
+	assert.Equal(t, []time.Duration{250 * time.Millisecond, 250 * time.Millisecond}, *sleeps)
+	assert.True(t, SetRequestsPerSecond("Test", 1))
+	assert.False(t, SetRequestsPerSecond("Unknown", 1))
+}
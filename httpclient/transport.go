@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"go-cloc/logger"
+	"net/http"
+	"net/url"
+	"os"
+
+	gitclient "github.com/go-git/go-git/v5/plumbing/transport/client"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+)
+
+// TransportOptions configures the network access of API requests, archive downloads and git over HTTPS
+type TransportOptions struct {
+	// ProxyURL is used for every request. When empty, the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables are used
+	ProxyURL string
+	// CABundlePath is a PEM file of certificate authorities trusted in addition to the system ones
+	CABundlePath string
+	// ClientCertPath and ClientKeyPath are the PEM certificate and key used for mutual TLS
+	ClientCertPath string
+	ClientKeyPath  string
+	// InsecureSkipVerify disables TLS certificate verification, only meant for lab environments
+	InsecureSkipVerify bool
+}
+
+// NewTransport creates an HTTP transport from the options
+func NewTransport(options TransportOptions) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig := &tls.Config{}
+
+	if options.ProxyURL != "" {
+		proxyURL, err := url.Parse(options.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", options.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if options.CABundlePath != "" {
+		pemData, err := os.ReadFile(options.CABundlePath)
+		if err != nil {
+			return nil, err
+		}
+		rootCAs, err := x509.SystemCertPool()
+		if err != nil {
+			logger.Debug("Failed to load the system certificate authorities: ", err)
+			rootCAs = x509.NewCertPool()
+		}
+		if !rootCAs.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", options.CABundlePath)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	if options.ClientCertPath != "" || options.ClientKeyPath != "" {
+		if options.ClientCertPath == "" || options.ClientKeyPath == "" {
+			return nil, errors.New("a client certificate and a client key are both required for mutual TLS")
+		}
+		certificate, err := tls.LoadX509KeyPair(options.ClientCertPath, options.ClientKeyPath)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	if options.InsecureSkipVerify {
+		logger.Warn("TLS certificate verification is disabled")
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// Configure applies the options to every client, and to cloning over HTTP and HTTPS with go-git
+func Configure(options TransportOptions) error {
+	transport, err := NewTransport(options)
+	if err != nil {
+		return err
+	}
+
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+	for _, client := range clients {
+		client.HTTPClient.Transport = transport
+	}
+	// clients created afterwards use the same transport
+	defaultTransport = transport
+
+	gitHTTPClient := githttp.NewClient(&http.Client{Transport: transport})
+	gitclient.InstallProtocol("https", gitHTTPClient)
+	gitclient.InstallProtocol("http", gitHTTPClient)
+	return nil
+}
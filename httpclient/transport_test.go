@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_httpclient_NewTransport_ca_bundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	caBundlePath := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caBundlePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644)
+
+	for _, testCase := range []struct {
+		options   TransportOptions
+		succeeded bool
+	}{
+		{TransportOptions{}, false},
+		{TransportOptions{CABundlePath: caBundlePath}, true},
+		{TransportOptions{InsecureSkipVerify: true}, true},
+	} {
+		transport, err := NewTransport(testCase.options)
+		assert.NoError(t, err)
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+
+		// Assert
+		if testCase.succeeded {
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		} else {
+			assert.Error(t, err)
+		}
+	}
+}
+
+func Test_httpclient_NewTransport_invalid_options(t *testing.T) {
+	emptyPath := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(emptyPath, []byte{}, 0644)
+
+	_, err := NewTransport(TransportOptions{CABundlePath: emptyPath})
+	assert.Error(t, err)
+	_, err = NewTransport(TransportOptions{ClientCertPath: "client.pem"})
+	assert.EqualError(t, err, "a client certificate and a client key are both required for mutual TLS")
+	_, err = NewTransport(TransportOptions{ProxyURL: "not a url"})
+	assert.Error(t, err)
+}
+
+func Test_httpclient_NewTransport_proxy(t *testing.T) {
+	transport, err := NewTransport(TransportOptions{ProxyURL: "http://proxy.example.com:8080"})
+	assert.NoError(t, err)
+	req, _ := http.NewRequest("GET", "https://api.github.com", nil)
+	proxyURL, err := transport.Proxy(req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "http://proxy.example.com:8080", proxyURL.String())
+}
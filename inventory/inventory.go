@@ -0,0 +1,248 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"go-cloc/devops"
+	"go-cloc/logger"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported inventory formats
+const (
+	CSV  string = "csv"
+	JSON string = "json"
+)
+
+// Entry is a single repository in an inventory file
+type Entry struct {
+	Id               string    `json:"id"`
+	Provider         string    `json:"provider"`
+	OrganizationName string    `json:"organization"`
+	ProjectName      string    `json:"project"`
+	ProjectKey       string    `json:"projectKey"`
+	RepositoryName   string    `json:"repository"`
+	Namespace        string    `json:"namespace"`
+	Slug             string    `json:"slug"`
+	DefaultBranch    string    `json:"defaultBranch"`
+	CloneURL         string    `json:"cloneUrl"`
+	LocalPath        string    `json:"localPath"`
+	Archived         bool      `json:"archived"`
+	Fork             bool      `json:"fork"`
+	Mirror           bool      `json:"mirror"`
+	Template         bool      `json:"template"`
+	Empty            bool      `json:"empty"`
+	Visibility       string    `json:"visibility"`
+	SizeKB           int64     `json:"sizeKB"`
+	LastPushed       time.Time `json:"lastPushed"`
+	Topics           []string  `json:"topics"`
+	PrimaryLanguage  string    `json:"primaryLanguage"`
+}
+
+// Column order of the CSV inventory
+var csvHeader = []string{
+	"id", "provider", "organization", "project", "projectKey", "repository", "namespace", "slug", "defaultBranch", "cloneUrl", "localPath",
+	"archived", "fork", "mirror", "template", "empty", "visibility", "sizeKB", "lastPushed", "topics", "primaryLanguage",
+}
+
+// NewEntry converts a RepoInfo into an inventory entry
+func NewEntry(provider string, repoInfo devops.RepoInfo) Entry {
+	return Entry{
+		Id:               repoInfo.Id,
+		Provider:         provider,
+		OrganizationName: repoInfo.OrganizationName,
+		ProjectName:      repoInfo.ProjectName,
+		ProjectKey:       repoInfo.ProjectKey,
+		RepositoryName:   repoInfo.RepositoryName,
+		Namespace:        repoInfo.Namespace,
+		Slug:             repoInfo.Slug,
+		DefaultBranch:    repoInfo.DefaultBranch,
+		CloneURL:         logger.Redact(repoInfo.CloneURL), // credentials embedded in URLs are not exported
+		LocalPath:        repoInfo.LocalPath,
+		Archived:         repoInfo.Archived,
+		Fork:             repoInfo.Fork,
+		Mirror:           repoInfo.Mirror,
+		Template:         repoInfo.Template,
+		Empty:            repoInfo.Empty,
+		Visibility:       repoInfo.Visibility,
+		SizeKB:           repoInfo.SizeKB,
+		LastPushed:       repoInfo.LastPushed,
+		Topics:           repoInfo.Topics,
+		PrimaryLanguage:  repoInfo.PrimaryLanguage,
+	}
+}
+
+// RepoInfo converts the inventory entry back into a RepoInfo. The Id from the inventory is kept so edits are respected
+func (entry Entry) RepoInfo() devops.RepoInfo {
+	repoInfo := devops.NewRepoInfo(entry.OrganizationName, entry.ProjectName, entry.RepositoryName, entry.DefaultBranch)
+	if entry.Id != "" {
+		repoInfo.Id = entry.Id
+	}
+	if entry.Namespace != "" {
+		repoInfo.Namespace = entry.Namespace
+	}
+	if entry.Slug != "" {
+		repoInfo.Slug = entry.Slug
+	}
+	repoInfo.ProjectKey = entry.ProjectKey
+	repoInfo.CloneURL = entry.CloneURL
+	repoInfo.LocalPath = entry.LocalPath
+	repoInfo.Archived = entry.Archived
+	repoInfo.Fork = entry.Fork
+	repoInfo.Mirror = entry.Mirror
+	repoInfo.Template = entry.Template
+	repoInfo.Empty = entry.Empty
+	repoInfo.Visibility = entry.Visibility
+	repoInfo.SizeKB = entry.SizeKB
+	repoInfo.LastPushed = entry.LastPushed
+	repoInfo.Topics = entry.Topics
+	repoInfo.PrimaryLanguage = entry.PrimaryLanguage
+	return repoInfo
+}
+
+// FormatFromPath returns the inventory format based on the file extension, defaulting to CSV
+func FormatFromPath(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return JSON
+	}
+	return CSV
+}
+
+func convertEntryIntoRecord(entry Entry) []string {
+	lastPushed := ""
+	if !entry.LastPushed.IsZero() {
+		lastPushed = entry.LastPushed.Format(time.RFC3339)
+	}
+	return []string{
+		entry.Id, entry.Provider, entry.OrganizationName, entry.ProjectName, entry.ProjectKey, entry.RepositoryName, entry.Namespace, entry.Slug, entry.DefaultBranch, entry.CloneURL, entry.LocalPath,
+		strconv.FormatBool(entry.Archived), strconv.FormatBool(entry.Fork), strconv.FormatBool(entry.Mirror), strconv.FormatBool(entry.Template), strconv.FormatBool(entry.Empty),
+		entry.Visibility, strconv.FormatInt(entry.SizeKB, 10), lastPushed, strings.Join(entry.Topics, ";"), entry.PrimaryLanguage,
+	}
+}
+
+func convertRecordIntoEntry(header []string, record []string) (Entry, error) {
+	values := map[string]string{}
+	for index, column := range header {
+		if index < len(record) {
+			values[column] = strings.TrimSpace(record[index])
+		}
+	}
+
+	entry := Entry{
+		Id:               values["id"],
+		Provider:         values["provider"],
+		OrganizationName: values["organization"],
+		ProjectName:      values["project"],
+		ProjectKey:       values["projectKey"],
+		RepositoryName:   values["repository"],
+		Namespace:        values["namespace"],
+		Slug:             values["slug"],
+		DefaultBranch:    values["defaultBranch"],
+		CloneURL:         values["cloneUrl"],
+		LocalPath:        values["localPath"],
+		Visibility:       values["visibility"],
+		PrimaryLanguage:  values["primaryLanguage"],
+	}
+	// optional columns may be left empty when editing the inventory by hand
+	entry.Archived, _ = strconv.ParseBool(values["archived"])
+	entry.Fork, _ = strconv.ParseBool(values["fork"])
+	entry.Mirror, _ = strconv.ParseBool(values["mirror"])
+	entry.Template, _ = strconv.ParseBool(values["template"])
+	entry.Empty, _ = strconv.ParseBool(values["empty"])
+	if values["sizeKB"] != "" {
+		sizeKB, err := strconv.ParseInt(values["sizeKB"], 10, 64)
+		if err != nil {
+			return entry, fmt.Errorf("invalid sizeKB %q for %s: %w", values["sizeKB"], entry.RepositoryName, err)
+		}
+		entry.SizeKB = sizeKB
+	}
+	if values["lastPushed"] != "" {
+		lastPushed, err := time.Parse(time.RFC3339, values["lastPushed"])
+		if err != nil {
+			return entry, fmt.Errorf("invalid lastPushed %q for %s: %w", values["lastPushed"], entry.RepositoryName, err)
+		}
+		entry.LastPushed = lastPushed
+	}
+	if values["topics"] != "" {
+		entry.Topics = strings.Split(values["topics"], ";")
+	}
+	if entry.RepositoryName == "" {
+		return entry, fmt.Errorf("missing repository name in row %v", record)
+	}
+	return entry, nil
+}
+
+// WriteInventory writes the repositories to an inventory file in the given format
+func WriteInventory(outputFilePath string, format string, provider string, repoInfos []devops.RepoInfo) error {
+	entries := []Entry{}
+	for _, repoInfo := range repoInfos {
+		entries = append(entries, NewEntry(provider, repoInfo))
+	}
+
+	f, err := os.Create(outputFilePath)
+	if err != nil {
+		logger.Error("Error creating inventory file: ", err)
+		return err
+	}
+	defer f.Close()
+
+	if format == JSON {
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := w.Write(convertEntryIntoRecord(entry)); err != nil {
+			logger.Error("Error writing to inventory: ", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadInventory reads an inventory file written by WriteInventory, possibly edited by hand.
+// The format is based on the file extension
+func ReadInventory(path string) ([]Entry, error) {
+	logger.Debug("Reading inventory file ", path)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []Entry{}
+	if FormatFromPath(path) == JSON {
+		if err := json.NewDecoder(f).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return entries, nil
+	}
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return entries, nil
+	}
+	header := records[0]
+	for _, record := range records[1:] {
+		entry, err := convertRecordIntoEntry(header, record)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
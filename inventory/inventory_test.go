@@ -0,0 +1,56 @@
+package inventory
+
+import (
+	"go-cloc/devops"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestRepoInfo() devops.RepoInfo {
+	repoInfo := devops.NewRepoInfo("org", "team/backend", "My Service", "main")
+	repoInfo.Namespace = "org/team/backend"
+	repoInfo.Slug = "my-service"
+	repoInfo.Archived = true
+	repoInfo.Visibility = "private"
+	repoInfo.SizeKB = 2048
+	repoInfo.LastPushed = time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	repoInfo.Topics = []string{"java", "payments"}
+	repoInfo.PrimaryLanguage = "Java"
+	return repoInfo
+}
+
+func Test_inventory_WriteInventory_ReadInventory(t *testing.T) {
+	for _, format := range []string{CSV, JSON} {
+		path := filepath.Join(t.TempDir(), "inventory."+format)
+		repoInfo := createTestRepoInfo()
+
+		err := WriteInventory(path, format, "GitLab", []devops.RepoInfo{repoInfo})
+		assert.NoError(t, err)
+		entries, err := ReadInventory(path)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(entries))
+		assert.Equal(t, "GitLab", entries[0].Provider)
+		assert.Equal(t, repoInfo, entries[0].RepoInfo())
+	}
+}
+
+func Test_inventory_ReadInventory_edited_csv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.csv")
+	os.WriteFile(path, []byte("organization,repository,defaultBranch\norg,my-repo,develop\n"), 0644)
+
+	entries, err := ReadInventory(path)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(entries))
+	repoInfo := entries[0].RepoInfo()
+	assert.Equal(t, "org-my-repo", repoInfo.Id)
+	assert.Equal(t, "my-repo", repoInfo.Slug)
+	assert.Equal(t, "develop", repoInfo.DefaultBranch)
+}
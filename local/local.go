@@ -0,0 +1,68 @@
+package local
+
+import (
+	"go-cloc/devops"
+	"go-cloc/logger"
+	"os"
+	"path/filepath"
+)
+
+// isGitRepo checks if the directory contains a .git folder or file (worktrees and submodules use a file)
+func isGitRepo(dirPath string) bool {
+	_, err := os.Stat(filepath.Join(dirPath, ".git"))
+	return err == nil
+}
+
+// newLocalRepoInfo creates a RepoInfo for a directory relative to the root directory
+func newLocalRepoInfo(rootPath string, dirPath string) devops.RepoInfo {
+	repoName, err := filepath.Rel(rootPath, dirPath)
+	if err != nil {
+		logger.LogStackTraceAndExit(err)
+	}
+	organization := filepath.Base(filepath.Clean(rootPath))
+	repoInfo := devops.NewRepoInfo(organization, "", filepath.ToSlash(repoName), "")
+	repoInfo.LocalPath = dirPath
+	return repoInfo
+}
+
+// DiscoverReposLocal treats each directory under rootPath as its own repository.
+// If gitReposOnly is false, every immediate child directory is a repository.
+// If gitReposOnly is true, the directory tree is searched for directories containing .git, without descending into them.
+func DiscoverReposLocal(rootPath string, gitReposOnly bool) []devops.RepoInfo {
+	repoInfos := []devops.RepoInfo{}
+
+	if !gitReposOnly {
+		entries, err := os.ReadDir(rootPath)
+		if err != nil {
+			logger.LogStackTraceAndExit(err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				logger.Debug("Skipping file ", entry.Name(), " since it is not a directory")
+				continue
+			}
+			repoInfos = append(repoInfos, newLocalRepoInfo(rootPath, filepath.Join(rootPath, entry.Name())))
+		}
+		return repoInfos
+	}
+
+	err := filepath.WalkDir(rootPath, func(path string, info os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || path == rootPath {
+			return nil
+		}
+		if isGitRepo(path) {
+			logger.Debug("Found git repository ", path)
+			repoInfos = append(repoInfos, newLocalRepoInfo(rootPath, path))
+			// nested repositories such as submodules are counted as part of their parent
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		logger.LogStackTraceAndExit(err)
+	}
+	return repoInfos
+}
@@ -0,0 +1,44 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createDirs(t *testing.T, root string, dirs ...string) {
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_local_DiscoverReposLocal_child_directories(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "checkouts")
+	createDirs(t, root, "service-a", "service-b/src")
+	os.WriteFile(filepath.Join(root, "notes.txt"), []byte("not a repo"), 0644)
+
+	result := DiscoverReposLocal(root, false)
+
+	// Assert
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "service-a", result[0].RepositoryName)
+	assert.Equal(t, "checkouts-service-a", result[0].Id)
+	assert.Equal(t, filepath.Join(root, "service-b"), result[1].LocalPath)
+}
+
+func Test_local_DiscoverReposLocal_git_repos_only(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "checkouts")
+	createDirs(t, root, "plain-dir", "service-a/.git", "team/service-b/.git", "team/service-b/vendor/lib/.git")
+
+	result := DiscoverReposLocal(root, true)
+
+	// Assert
+	assert.Equal(t, 2, len(result))
+	assert.Equal(t, "service-a", result[0].RepositoryName)
+	assert.Equal(t, "team/service-b", result[1].RepositoryName)
+	assert.Equal(t, "checkouts-team-service-b", result[1].Id)
+}
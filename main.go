@@ -1,19 +1,32 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"go-cloc/azuredevops"
 	"go-cloc/bitbucket"
 	"go-cloc/clone"
 	"go-cloc/devops"
+	"go-cloc/estimate"
+	"go-cloc/gitea"
 	"go-cloc/github"
 	"go-cloc/gitlab"
+	"go-cloc/gitlist"
+	"go-cloc/inventory"
+	"go-cloc/local"
 	"go-cloc/logger"
 	"go-cloc/report"
 	"go-cloc/scanner"
 	"go-cloc/utilities"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
 )
 
 // pseduocode
@@ -27,122 +40,247 @@ import (
 // combine csv reports
 // report on any failed repos
 
-// contains checks if a slice contains a specific string
-func contains(slice []string, item string) bool {
-	for _, str := range slice {
-		if str == item {
-			return true
+func main() {
+	command, commandArgs := utilities.ParseCommand(os.Args[1:])
+	if command == utilities.SCAN || command == utilities.DISCOVER {
+		// parse CLI arguments and store them in a struct
+		args := utilities.ParseScanArgs(command, commandArgs)
+		if args.ConfigFilePath != "" {
+			RunConfig(utilities.ParseConfigSources(args))
+		} else {
+			Scan(args)
 		}
+	} else if command == utilities.REPORT {
+		RenderReport(utilities.ParseReportArgs(commandArgs))
+	} else if command == utilities.DIFF {
+		Diff(utilities.ParseDiffArgs(commandArgs))
+	} else if command == utilities.LANGUAGES {
+		utilities.ParseCommandWithoutFlags(command, commandArgs)
+		ListLanguages()
+	} else if command == utilities.VERSION {
+		utilities.ParseCommandWithoutFlags(command, commandArgs)
+		fmt.Println("go-cloc", utilities.Version)
+	} else {
+		utilities.PrintUsage(os.Stdout)
 	}
-	return false
 }
 
-func main() {
-	// parse CLI arguments and store them in a struct
-	args := utilities.ParseArgsFromCLI()
+// Scan discovers, filters, clones and scans the repositories, or only writes an inventory for discover and --dry-run
+func Scan(args utilities.CLIArgs) {
+	startedAt := time.Now()
+
+	// authenticate as a GitHub App, the installation token is refreshed before each repository since it expires after an hour
+	githubApp := CreateGithubAppTokenSource(args)
+	if githubApp != nil {
+		args.AccessToken = githubApp.Token()
+	}
+
+	fitleredRepoInfoArr := DiscoverAndFilterRepositories(args)
+	numRepos := len(fitleredRepoInfoArr)
+
+	if args.ListRepos {
+		// only list the repositories, nothing gets cloned or scanned
+		return
+	}
+
+	// create output folder, unless only an inventory is written to an explicit path
+	if args.DumpCSVs && !(args.DryRun && args.InventoryFilePath != "") {
+		logger.Debug("Creating folder ", args.ResultsDirectoryPath, " to store results")
+		err := os.Mkdir(args.ResultsDirectoryPath, 0777)
+		if err != nil {
+			logger.LogStackTraceAndExit(err)
+		}
+	}
+
+	if args.DryRun {
+		// only write the inventory, nothing gets cloned or scanned
+		inventoryFilePath := args.InventoryFilePath
+		if inventoryFilePath == "" {
+			inventoryFilePath = filepath.Join(args.ResultsDirectoryPath, "AAA-repository-inventory."+args.InventoryFormat)
+		}
+		err := inventory.WriteInventory(inventoryFilePath, args.InventoryFormat, args.Mode, fitleredRepoInfoArr)
+		if err != nil {
+			logger.LogStackTraceAndExit(err)
+		}
+		logger.Info("Inventory of ", numRepos, " repositories can be found ", inventoryFilePath)
+		return
+	}
 
+	if args.Estimate {
+		// only estimate LOC from the language statistics, nothing gets cloned or scanned
+		EstimateRepositories(args, githubApp, fitleredRepoInfoArr)
+		return
+	}
+
+	results := ScanRepositories(args, githubApp, fitleredRepoInfoArr)
+	results.StartedAt = startedAt
+	WriteReports(args, results)
+}
+
+// RunConfig scans every source of a configuration file, then writes one combined report
+func RunConfig(outputArgs utilities.CLIArgs, sourcesArgs []utilities.CLIArgs) {
+	if outputArgs.DumpCSVs {
+		logger.Debug("Creating folder ", outputArgs.ResultsDirectoryPath, " to store results")
+		err := os.Mkdir(outputArgs.ResultsDirectoryPath, 0777)
+		if err != nil {
+			logger.LogStackTraceAndExit(err)
+		}
+	}
+
+	results := ScanResults{StartedAt: time.Now(), Calibration: estimate.Calibration{}}
+	for index, args := range sourcesArgs {
+		logger.Info("Source ", (index + 1), "/", len(sourcesArgs), " ", args.SourceName, " (", args.Mode, ")")
+		githubApp := CreateGithubAppTokenSource(args)
+		if githubApp != nil {
+			args.AccessToken = githubApp.Token()
+		}
+		repoInfoArr := DiscoverAndFilterRepositories(args)
+		// prefix the ids with the source name, so repositories of different sources never share a results file
+		for repoIndex := range repoInfoArr {
+			repoInfoArr[repoIndex].Id = args.SourceName + "-" + repoInfoArr[repoIndex].Id
+		}
+		results.Merge(ScanRepositories(args, githubApp, repoInfoArr))
+	}
+	WriteReports(outputArgs, results)
+}
+
+// ScanResults are the results of scanning repositories, combined into the reports by WriteReports
+type ScanResults struct {
+	StartedAt     time.Time
+	NumRepos      int
+	FailedRepos   []report.FailedRepo
+	RepoResults   []report.RepoTotal
+	BranchResults []report.BranchTotal
+	// bytes and LOC per language, so future estimates can be calibrated with this scan
+	Calibration estimate.Calibration
+}
+
+// Merge adds the results of another scan, e.g. of the next source of a configuration file
+func (results *ScanResults) Merge(other ScanResults) {
+	results.NumRepos += other.NumRepos
+	results.FailedRepos = append(results.FailedRepos, other.FailedRepos...)
+	results.RepoResults = append(results.RepoResults, other.RepoResults...)
+	results.BranchResults = append(results.BranchResults, other.BranchResults...)
+	for language, total := range other.Calibration {
+		if _, ok := results.Calibration[language]; !ok {
+			results.Calibration[language] = &estimate.LanguageTotal{}
+		}
+		results.Calibration[language].Bytes += total.Bytes
+		results.Calibration[language].CodeLineCount += total.CodeLineCount
+	}
+}
+
+// DiscoverAndFilterRepositories discovers the repositories, then keeps those passing the filters and include / exclude rules
+func DiscoverAndFilterRepositories(args utilities.CLIArgs) []devops.RepoInfo {
 	// Discover repositories
 	logger.Info("Discovering repositories...")
-	repositoryInfoArr := DiscoverRepositories(args.Mode, args.AccessToken, args.Organization)
+	repositoryInfoArr := DiscoverRepositories(args)
 	initialNumReposFound := len(repositoryInfoArr)
 	logger.Info("Discovered ", initialNumReposFound, " repositories in ", args.Organization)
 
-	// Filter repositories
+	// Filter repositories using the metadata reported by the DevOps platform, then the include / exclude rules
 	logger.Info("Including / Excluding repositories...")
 	fitleredRepoInfoArr := []devops.RepoInfo{}
 	for _, repoInfo := range repositoryInfoArr {
 		logger.Debug("Checking repo ", repoInfo.RepositoryName, " for exclusion")
-		// check if we should include or exclude this repo
-		if contains(args.ExcludeRepositories, repoInfo.RepositoryName) {
-			logger.Debug("Excluding ", repoInfo.RepositoryName, " as it is in the exclude list")
-			continue
+		included := false
+		reason := args.RepoFilter.ExcludeReason(repoInfo)
+		if reason != "" {
+			reason = "excluded as " + reason
+		} else {
+			included, reason = devops.EvaluateRepoRules(repoInfo, args.IncludeRepositories, args.ExcludeRepositories)
 		}
 
-		// check if we should include this repo
-		if len(args.IncludeRepositories) > 0 && !contains(args.IncludeRepositories, repoInfo.RepositoryName) {
-			logger.Debug("Excluding ", repoInfo.RepositoryName, " as it is NOT in the include list")
+		if args.ListRepos {
+			// dry run, print the decision for every repository
+			decision := "EXCLUDE"
+			if included {
+				decision = "INCLUDE"
+			}
+			logger.Info(decision, " ", repoInfo.Id, " - ", reason)
+		}
+		logger.Debug(repoInfo.RepositoryName, " is ", reason)
+		if !included {
 			continue
 		}
-
-		logger.Debug("Including ", repoInfo.RepositoryName)
 		fitleredRepoInfoArr = append(fitleredRepoInfoArr, repoInfo)
 	}
-	numRepos := len(fitleredRepoInfoArr)
-
-	// create output folder
-	if args.DumpCSVs {
-		logger.Debug("Creating folder ", args.ResultsDirectoryPath, " to store results")
-		err := os.Mkdir(args.ResultsDirectoryPath, 0777)
-		if err != nil {
-			logger.LogStackTraceAndExit(err)
-		}
-	}
+	logger.Info("Including ", len(fitleredRepoInfoArr), "/", initialNumReposFound, " repositories")
+	return fitleredRepoInfoArr
+}
 
-	failedRepos := []devops.RepoInfo{}
-	allRepoResults := []report.RepoTotal{}
+// ScanRepositories clones and scans every repository, writing the results by file of each repository
+func ScanRepositories(args utilities.CLIArgs, githubApp *github.AppTokenSource, repoInfoArr []devops.RepoInfo) ScanResults {
+	results := ScanResults{NumRepos: len(repoInfoArr), Calibration: estimate.Calibration{}}
 	// for each repo, clone and scan
-	for index, repoInfo := range fitleredRepoInfoArr {
-		// set directory
-		clonedRepoDir := ""
-		logger.Debug("Setting directory for ", repoInfo.RepositoryName, " to begin scanning")
-		if args.Mode == utilities.LOCAL {
-			// set directory or file to local file
-			clonedRepoDir = args.LocalScanFilePath
-			logger.Debug("Local file scan path is ", args.LocalScanFilePath)
-		} else {
+	for index, repoInfo := range repoInfoArr {
+		// print status
+		logger.Info((index + 1), "/", len(repoInfoArr), " processing respository ", repoInfo.RepositoryName, "...")
+		if githubApp != nil {
+			args.AccessToken = githubApp.Token()
+		}
 
-			// print status
-			logger.Info((index + 1), "/", len(fitleredRepoInfoArr), " cloning respository ", repoInfo.RepositoryName, "...")
-
-			// TODO: add support for cloning using zip for more platforms
-			if args.CloneRepoUsingZip {
-				logger.Debug("Cloning using zip")
-
-				clonedRepoDir = CloneRepoUsingZip(args.Mode, args.AccessToken, repoInfo)
-				if clonedRepoDir == "" {
-					// Failed to clone repo, save metadata for later reporting
-					failedRepos = append(failedRepos, repoInfo)
-					logger.Error("Failed to clone repo ", repoInfo.RepositoryName)
-					// skip to the next repo
-					continue
-				}
-			} else {
-				logger.Debug("Cloning using git clone")
-				// clone repo
-				clonedRepoDir = CloneRepo(args.Mode, args.AccessToken, args.Organization, repoInfo)
-
-				// Handle failed repos
-				if clonedRepoDir == "" {
-					// Failed to clone repo, save metadata for later reporting
-					failedRepos = append(failedRepos, repoInfo)
-					logger.Error("Failed to clone repo ", repoInfo.RepositoryName)
-					// skip to the next repo
-					continue
-				}
+		// scan every ref and keep the one with the most LOC
+		refs := ListRefsToScan(args, repoInfo)
+		selectedRef := ""
+		selectedFileScanResultsArr := []scanner.FileScanResults{}
+		selectedTotalResult := scanner.FileScanResults{}
+		repoBranchResults := []report.BranchTotal{}
+		scannedAnyRef := false
+		for _, ref := range refs {
+			fileScanResultsArr, repoTotalResult, ok := CloneAndScan(args, repoInfo, ref)
+			if !ok {
+				continue
 			}
+			// every scanned branch is reported, even when a single branch matched
+			if args.Branches != "" {
+				logger.Info("Total LOC for ", repoInfo.RepositoryName, " on branch ", ref, " is ", repoTotalResult.CodeLineCount)
+				repoBranchResults = append(repoBranchResults, report.BranchTotal{RepositoryId: repoInfo.Id, Branch: ref, CodeLineCount: repoTotalResult.CodeLineCount})
+			}
+			if !scannedAnyRef || repoTotalResult.CodeLineCount > selectedTotalResult.CodeLineCount {
+				selectedRef = ref
+				selectedFileScanResultsArr = fileScanResultsArr
+				selectedTotalResult = repoTotalResult
+			}
+			scannedAnyRef = true
 		}
 
-		// scan LOC for the directory
-		logger.Info("Scanning ", clonedRepoDir, "...")
-		filePaths := scanner.WalkDirectory(clonedRepoDir, args.IgnorePatterns)
-		fileScanResultsArr := []scanner.FileScanResults{}
-		for _, filePath := range filePaths {
-			fileScanResultsArr = append(fileScanResultsArr, scanner.ScanFile(filePath))
+		// Handle failed repos
+		if !scannedAnyRef {
+			// Failed to clone repo, save metadata and the reason for later reporting
+			reason := "failed to clone"
+			if len(refs) == 0 {
+				reason = "no branch matching " + args.Branches + " could be listed"
+			} else if args.CloneRepoUsingZip {
+				reason = "failed to download zip"
+			}
+			results.FailedRepos = append(results.FailedRepos, report.FailedRepo{RepositoryId: repoInfo.Id, OrganizationName: repoInfo.OrganizationName, RepositoryName: repoInfo.RepositoryName, Reason: reason})
+			logger.Error("Failed to clone repo ", repoInfo.RepositoryName)
+			// skip to the next repo
+			continue
 		}
 
-		logger.Debug("Calculating total LOC for ", repoInfo.RepositoryName)
-
-		// sort and calculate total LOC
-		fileScanResultsArr = report.SortFileScanResults(fileScanResultsArr)
-		repoTotalResult := report.CalculateTotalLineOfCode(fileScanResultsArr)
+		// mark which branch was selected
+		for branchIndex := range repoBranchResults {
+			repoBranchResults[branchIndex].Selected = repoBranchResults[branchIndex].Branch == selectedRef
+		}
+		results.BranchResults = append(results.BranchResults, repoBranchResults...)
+		if args.Branches != "" {
+			logger.Info("Selected branch ", selectedRef, " for ", repoInfo.RepositoryName)
+		}
 
-		logger.Info("Total LOC for ", repoInfo.RepositoryName, " is ", repoTotalResult.CodeLineCount)
+		logger.Info("Total LOC for ", repoInfo.RepositoryName, " is ", selectedTotalResult.CodeLineCount)
+		results.Calibration.Add(selectedFileScanResultsArr)
 
-		// append results to allRepoResults
-		allRepoResults = append(allRepoResults, report.RepoTotal{RepositoryId: repoInfo.Id, CodeLineCount: repoTotalResult.CodeLineCount})
+		// append results to the repo results, keeping the results by file only for the formats reporting them
+		repoResult := report.RepoTotal{RepositoryId: repoInfo.Id, OrganizationName: repoInfo.OrganizationName, ProjectName: repoInfo.ProjectName, Branch: selectedRef, CodeLineCount: selectedTotalResult.CodeLineCount, BlankLineCount: selectedTotalResult.BlankLineCount, CommentsLineCount: selectedTotalResult.CommentsLineCount}
+		if report.KeepsFileResults(args.Format) {
+			repoResult.Files = selectedFileScanResultsArr
+		}
+		results.RepoResults = append(results.RepoResults, repoResult)
 
 		// convert results into records for CSV or command line output
-		records := report.ConvertFileResultsIntoRecords(fileScanResultsArr, repoTotalResult)
+		records := report.ConvertFileResultsIntoRecords(selectedFileScanResultsArr, selectedTotalResult)
 
 		// Dump results by file in a csv
 		if args.DumpCSVs {
@@ -155,32 +293,29 @@ func main() {
 			logger.Info("Results by file for ", repoInfo.RepositoryName, ":")
 			report.PrintCsv(records)
 		}
-
-		// clean up cloned repo after scan completes
-		if args.Mode == utilities.LOCAL {
-			// do not delete the directory if we are scanning a local file or directory
-		} else {
-			// delete the cloned repo directory after scanning
-			logger.Debug("Deleting directory ", clonedRepoDir)
-			err := os.RemoveAll(clonedRepoDir)
-			if err != nil {
-				logger.Error("Failed to remove directory: ", clonedRepoDir)
-			}
-		}
 	}
+	return results
+}
 
+// WriteReports writes the combined reports and prints the total LOC
+func WriteReports(args utilities.CLIArgs, results ScanResults) {
 	// print failed repos
-	numFailedRepos := len(failedRepos)
+	numFailedRepos := len(results.FailedRepos)
 	if numFailedRepos > 0 {
-		logger.Info(numFailedRepos, "/", numRepos, " failed to process. See below for a list")
-		for _, failedRepo := range failedRepos {
-			logger.Info(failedRepo.RepositoryName, " - ", failedRepo.Id)
+		logger.Info(numFailedRepos, "/", results.NumRepos, " failed to process. See below for a list")
+		for _, failedRepo := range results.FailedRepos {
+			logger.Info(failedRepo.RepositoryName, " - ", failedRepo.RepositoryId, " - ", failedRepo.Reason)
 		}
 	} else {
 		logger.Info("0 repos failed to scan.")
 	}
+	if args.DumpCSVs {
+		failedReportCSVFilePath := filepath.Join(args.ResultsDirectoryPath, report.FAILEDREPORTFILENAME)
+		logger.Debug("Dumping failed repos to ", failedReportCSVFilePath)
+		report.WriteCsv(failedReportCSVFilePath, report.ConvertFailedReposIntoRecords(results.FailedRepos))
+	}
 
-	allRepoResults = report.SortRepoTotalResults(allRepoResults)
+	allRepoResults := report.SortRepoTotalResults(results.RepoResults)
 
 	logger.Debug("Calculating total LOC for ", args.Organization)
 	// sum total LOC for all repos
@@ -194,7 +329,7 @@ func main() {
 
 	// dump combined csv reports
 	if args.DumpCSVs {
-		combinedReportsCSVFilePath := filepath.Join(args.ResultsDirectoryPath, "AAA-combined-total-lines.csv")
+		combinedReportsCSVFilePath := filepath.Join(args.ResultsDirectoryPath, report.COMBINEDREPORTFILENAME)
 		logger.Debug("Dumping total results by file to ", combinedReportsCSVFilePath)
 		report.WriteCsv(combinedReportsCSVFilePath, records)
 		logger.Info("Total LOC results can be found ", combinedReportsCSVFilePath)
@@ -202,28 +337,422 @@ func main() {
 		report.PrintCsv(records)
 	}
 
+	// dump totals rolled up per organization, when more than one organization was scanned
+	organizationNames := map[string]bool{}
+	for _, repoResult := range allRepoResults {
+		organizationNames[repoResult.OrganizationName] = true
+	}
+	if len(organizationNames) > 1 {
+		organizationRecords := report.ConvertOrganizationTotalsIntoRecords(allRepoResults)
+		if args.DumpCSVs {
+			organizationReportCSVFilePath := filepath.Join(args.ResultsDirectoryPath, "AAA-combined-organization-total-lines.csv")
+			logger.Debug("Dumping total results by organization to ", organizationReportCSVFilePath)
+			report.WriteCsv(organizationReportCSVFilePath, organizationRecords)
+			logger.Info("Total LOC results by organization can be found ", organizationReportCSVFilePath)
+		} else {
+			report.PrintCsv(organizationRecords)
+		}
+	}
+
+	// dump totals per branch
+	if args.Branches != "" || len(results.BranchResults) > 0 {
+		branchRecords := report.ConvertBranchTotalsIntoRecords(results.BranchResults)
+		if args.DumpCSVs {
+			branchReportCSVFilePath := filepath.Join(args.ResultsDirectoryPath, "AAA-combined-branch-total-lines.csv")
+			logger.Debug("Dumping total results by branch to ", branchReportCSVFilePath)
+			report.WriteCsv(branchReportCSVFilePath, branchRecords)
+			logger.Info("Total LOC results by branch can be found ", branchReportCSVFilePath)
+		} else {
+			report.PrintCsv(branchRecords)
+		}
+	}
+
+	// dump totals rolled up per project
+	if args.GroupByProject {
+		projectRecords := report.ConvertProjectTotalsIntoRecords(allRepoResults)
+		if args.DumpCSVs {
+			projectReportCSVFilePath := filepath.Join(args.ResultsDirectoryPath, "AAA-combined-project-total-lines.csv")
+			logger.Debug("Dumping total results by project to ", projectReportCSVFilePath)
+			report.WriteCsv(projectReportCSVFilePath, projectRecords)
+			logger.Info("Total LOC results by project can be found ", projectReportCSVFilePath)
+		} else {
+			report.PrintCsv(projectRecords)
+		}
+	}
+
+	// dump the bytes per line of code of each language, used by --estimate --calibration-file
+	if args.DumpCSVs && len(results.Calibration) > 0 {
+		calibrationCSVFilePath := filepath.Join(args.ResultsDirectoryPath, "AAA-bytes-per-line.csv")
+		logger.Debug("Dumping bytes per line of code to ", calibrationCSVFilePath)
+		report.WriteCsv(calibrationCSVFilePath, estimate.ConvertCalibrationIntoRecords(results.Calibration))
+		logger.Info("Bytes per line of code for calibrating --estimate can be found ", calibrationCSVFilePath)
+	}
+
 	logger.Info("Total LOC for ", args.Organization, " is ", totalLoc)
 
+	if args.Format != report.CSV {
+		metadata := report.RunMetadata{
+			Tool:            "go-cloc",
+			Version:         utilities.Version,
+			Mode:            args.Mode,
+			Organization:    args.Organization,
+			StartedAt:       results.StartedAt,
+			FinishedAt:      time.Now(),
+			NumRepos:        results.NumRepos,
+			NumScannedRepos: len(allRepoResults),
+			NumFailedRepos:  numFailedRepos,
+		}
+		metadata.DurationSeconds = metadata.FinishedAt.Sub(metadata.StartedAt).Seconds()
+		options := CreateFormatOptions(args.BaselinePath, args.TopFiles)
+		WriteFormattedReport(args.OutputFilePath, func(writer io.Writer) error {
+			return report.WriteFormattedReportTo(writer, args.Format, metadata, allRepoResults, results.FailedRepos, options)
+		})
+		if args.OutputFilePath == "" {
+			// the report is the only output on standard output
+			return
+		}
+	}
+
 	// Print the total LOC to standard output to make it easy for external tools to parse
 	fmt.Println(totalLoc)
 }
 
-func CloneRepoUsingZip(mode string, accessToken string, repoInfo devops.RepoInfo) string {
+// CreateFormatOptions reads the baseline results, if any, for the formats comparing with a previous scan
+func CreateFormatOptions(baselinePath string, topFiles int) report.FormatOptions {
+	options := report.FormatOptions{TopFiles: topFiles}
+	if baselinePath != "" {
+		baseline, err := report.ReadRepoTotals(baselinePath)
+		if err != nil {
+			logger.Error("Failed to read baseline results: ", err)
+			os.Exit(-1)
+		}
+		options.Baseline = baseline
+	}
+	return options
+}
+
+// WriteFormattedReport writes a report to the output file, or to standard output if no file is given
+func WriteFormattedReport(outputFilePath string, write func(io.Writer) error) {
+	if outputFilePath == "" {
+		write(os.Stdout)
+		return
+	}
+	f, err := os.Create(outputFilePath)
+	if err != nil {
+		logger.Error("Failed to create ", outputFilePath, ": ", err)
+		os.Exit(-1)
+	}
+	defer f.Close()
+	if err := write(f); err != nil {
+		os.Exit(-1)
+	}
+	logger.Info("Report can be found ", outputFilePath)
+}
+
+// RenderReport renders the combined report of existing results again, recalculating the total
+func RenderReport(args utilities.ReportArgs) {
+	if args.Format != report.CSV {
+		// the other formats report the results by file and the failed repositories as well
+		repoTotals, failedRepos, err := report.ReadRepoResults(args.ResultsDirectoryPath)
+		if err != nil {
+			logger.Error("Failed to read results: ", err)
+			os.Exit(-1)
+		}
+		logger.Debug("Read ", len(repoTotals), " repositories and ", len(failedRepos), " failed repositories from ", args.ResultsDirectoryPath)
+		metadata := report.RunMetadata{
+			Tool:            "go-cloc",
+			Version:         utilities.Version,
+			NumRepos:        len(repoTotals) + len(failedRepos),
+			NumScannedRepos: len(repoTotals),
+			NumFailedRepos:  len(failedRepos),
+		}
+		options := CreateFormatOptions(args.BaselinePath, args.TopFiles)
+		WriteFormattedReport(args.OutputFilePath, func(writer io.Writer) error {
+			return report.WriteFormattedReportTo(writer, args.Format, metadata, report.SortRepoTotalResults(repoTotals), failedRepos, options)
+		})
+		return
+	}
+
+	repoTotals, err := report.ReadRepoTotals(args.ResultsDirectoryPath)
+	if err != nil {
+		logger.Error("Failed to read results: ", err)
+		os.Exit(-1)
+	}
+	logger.Debug("Read ", len(repoTotals), " repositories from ", args.ResultsDirectoryPath)
+	records := report.ConvertRepoTotalsIntoRecords(report.SortRepoTotalResults(repoTotals))
+	WriteCommandOutput(args.OutputFilePath, records)
+}
+
+// Diff compares the LOC of every repository in two results, e.g. two weekly scans
+func Diff(args utilities.DiffArgs) {
+	baseline, err := report.ReadRepoTotals(args.BaselinePath)
+	if err != nil {
+		logger.Error("Failed to read baseline results: ", err)
+		os.Exit(-1)
+	}
+	current, err := report.ReadRepoTotals(args.CurrentPath)
+	if err != nil {
+		logger.Error("Failed to read results: ", err)
+		os.Exit(-1)
+	}
+	WriteCommandOutput(args.OutputFilePath, report.ConvertRepoTotalsDiffIntoRecords(baseline, current))
+}
+
+// WriteCommandOutput writes the records to the output file, or to standard output if no file is given
+func WriteCommandOutput(outputFilePath string, records [][]string) {
+	if outputFilePath == "" {
+		report.WriteCsvTo(os.Stdout, records)
+		return
+	}
+	if err := report.WriteCsv(outputFilePath, records); err != nil {
+		os.Exit(-1)
+	}
+	logger.Info("Results can be found ", outputFilePath)
+}
+
+// ListLanguages prints the supported languages and their file extensions
+func ListLanguages() {
+	languages := []string{}
+	for language := range scanner.Languages {
+		languages = append(languages, language)
+	}
+	slices.Sort(languages)
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer writer.Flush()
+	fmt.Fprintln(writer, "LANGUAGE\tEXTENSIONS")
+	for _, language := range languages {
+		fmt.Fprintln(writer, language+"\t"+strings.Join(scanner.Languages[language].Extensions, " "))
+	}
+}
+
+// EstimateRepositories estimates the LOC of every repository from the language statistics of the DevOps platform,
+// then writes the estimated totals labelled as estimates
+func EstimateRepositories(args utilities.CLIArgs, githubApp *github.AppTokenSource, repoInfoArr []devops.RepoInfo) {
+	logger.Warn("LOC are estimated from the language statistics of ", args.Mode, ", no repository is cloned or scanned. Run without --estimate for exact results")
+	if args.Mode == utilities.GITLAB || args.Mode == utilities.BITBUCKET {
+		logger.Warn("The repository size reported by ", args.Mode, " includes the git history, so the estimates are inflated")
+	}
+
+	failedRepos := []report.FailedRepo{}
+	allRepoResults := []report.RepoTotal{}
+	allRepoEstimates := []estimate.RepoEstimate{}
+	for index, repoInfo := range repoInfoArr {
+		// print status
+		logger.Info((index + 1), "/", len(repoInfoArr), " estimating respository ", repoInfo.RepositoryName, "...")
+		if githubApp != nil {
+			args.AccessToken = githubApp.Token()
+		}
+
+		languageBytes, err := DiscoverLanguageBytes(args, repoInfo)
+		if err != nil {
+			failedRepos = append(failedRepos, report.FailedRepo{RepositoryId: repoInfo.Id, OrganizationName: repoInfo.OrganizationName, RepositoryName: repoInfo.RepositoryName, Reason: "failed to estimate: " + err.Error()})
+			logger.Error("Failed to get the languages of ", repoInfo.RepositoryName, ": ", err)
+			continue
+		}
+		languageEstimates := estimate.EstimateLanguages(languageBytes, args.EstimateFactors)
+		codeLineCount := estimate.TotalCodeLineCount(languageEstimates)
+		logger.Info("Estimated LOC for ", repoInfo.RepositoryName, " is ", codeLineCount)
+
+		allRepoEstimates = append(allRepoEstimates, estimate.RepoEstimate{RepositoryId: repoInfo.Id, Languages: languageEstimates})
+		allRepoResults = append(allRepoResults, report.RepoTotal{RepositoryId: repoInfo.Id, OrganizationName: repoInfo.OrganizationName, ProjectName: repoInfo.ProjectName, CodeLineCount: codeLineCount})
+	}
+
+	// print failed repos
+	if len(failedRepos) > 0 {
+		logger.Info(len(failedRepos), "/", len(repoInfoArr), " failed to estimate. See below for a list")
+		for _, failedRepo := range failedRepos {
+			logger.Info(failedRepo.RepositoryName, " - ", failedRepo.RepositoryId, " - ", failedRepo.Reason)
+		}
+	}
+	if args.DumpCSVs {
+		failedReportCSVFilePath := filepath.Join(args.ResultsDirectoryPath, report.FAILEDREPORTFILENAME)
+		logger.Debug("Dumping failed repos to ", failedReportCSVFilePath)
+		report.WriteCsv(failedReportCSVFilePath, report.ConvertFailedReposIntoRecords(failedRepos))
+	}
+
+	allRepoResults = report.SortRepoTotalResults(allRepoResults)
+	totalLoc := 0
+	for _, repoResult := range allRepoResults {
+		totalLoc += repoResult.CodeLineCount
+	}
+
+	// label the LOC column so the report cannot be mistaken for a scan
+	records := report.ConvertRepoTotalsIntoRecords(allRepoResults)
+	records[0][1] = "estimatedLineOfCodeCount"
+	languageRecords := estimate.ConvertEstimatesIntoRecords(allRepoEstimates)
+	if args.DumpCSVs {
+		combinedReportsCSVFilePath := filepath.Join(args.ResultsDirectoryPath, report.ESTIMATEDREPORTFILENAME)
+		logger.Debug("Dumping estimated total results to ", combinedReportsCSVFilePath)
+		report.WriteCsv(combinedReportsCSVFilePath, records)
+		languageReportCSVFilePath := filepath.Join(args.ResultsDirectoryPath, "AAA-estimated-language-lines.csv")
+		logger.Debug("Dumping estimated results by language to ", languageReportCSVFilePath)
+		report.WriteCsv(languageReportCSVFilePath, languageRecords)
+		logger.Info("Estimated LOC results can be found ", combinedReportsCSVFilePath, " and ", languageReportCSVFilePath)
+	} else {
+		report.PrintCsv(records)
+		report.PrintCsv(languageRecords)
+	}
+
+	logger.Info("Estimated total LOC for ", args.Organization, " is ", totalLoc, " (estimate, not a scan)")
+
+	// Print the total LOC to standard output to make it easy for external tools to parse
+	fmt.Println(totalLoc)
+}
+
+// DiscoverLanguageBytes returns the bytes of code per language of the default branch, as reported by the DevOps platform
+func DiscoverLanguageBytes(args utilities.CLIArgs, repoInfo devops.RepoInfo) (map[string]int64, error) {
+	if args.Mode == utilities.GITHUB {
+		return github.DiscoverLanguagesGithub(repoInfo.OrganizationName, repoInfo.RepositoryName, args.AccessToken)
+	} else if args.Mode == utilities.GITLAB {
+		// GitLab only reports percentages, which are applied to the repository size.
+		// The size is missing without at least reporter access, which would silently estimate 0 LOC
+		if repoInfo.SizeKB == 0 && !repoInfo.Empty {
+			return nil, errors.New("GitLab did not report the repository size, which requires at least the Reporter role")
+		}
+		percentages, err := gitlab.DiscoverLanguagesGitLab(repoInfo.Namespace, repoInfo.Slug, args.AccessToken)
+		if err != nil {
+			return nil, err
+		}
+		return estimate.ApportionBytes(percentages, repoInfo.SizeKB*1024), nil
+	} else if args.Mode == utilities.BITBUCKET {
+		// Bitbucket only reports the size and the main language of the repository
+		return map[string]int64{repoInfo.PrimaryLanguage: repoInfo.SizeKB * 1024}, nil
+	} else if args.Mode == utilities.GITEA {
+		return gitea.DiscoverLanguagesGitea(args.BaseURL, repoInfo.OrganizationName, repoInfo.RepositoryName, args.AccessToken)
+	}
+	return nil, fmt.Errorf("mode %s does not support --estimate", args.Mode)
+}
+
+// ListRefsToScan returns the refs to scan for a repository. An empty ref scans the default branch
+func ListRefsToScan(args utilities.CLIArgs, repoInfo devops.RepoInfo) []string {
+	if args.Ref != "" {
+		return []string{args.Ref}
+	}
+	if args.Branches == "" {
+		return []string{""}
+	}
+
+	// list the branches of the remote and keep the ones matching the glob
+	cloneRepoUrl := CreateCloneURL(args.Mode, args.CloneProtocol, args.BaseURL, repoInfo)
+	branches, err := clone.ListRemoteBranches(cloneRepoUrl, CreateCloneAuth(args, cloneRepoUrl))
+	if err != nil {
+		logger.Error("Failed to list branches for ", repoInfo.RepositoryName, ": ", err)
+		return []string{}
+	}
+	branchPattern := devops.CompileGlob(args.Branches)
+	refs := []string{}
+	for _, branch := range branches {
+		if branchPattern.MatchString(branch) {
+			refs = append(refs, branch)
+		}
+	}
+	logger.Debug("Branches to scan for ", repoInfo.RepositoryName, ": ", refs)
+	if len(refs) == 0 {
+		logger.Warn("No branches of ", repoInfo.RepositoryName, " match ", args.Branches)
+	}
+	return refs
+}
+
+// CloneAndScan clones a single ref of the repository, scans it and removes the clone again.
+// Returns false if the repository could not be cloned
+func CloneAndScan(args utilities.CLIArgs, repoInfo devops.RepoInfo, ref string) ([]scanner.FileScanResults, scanner.FileScanResults, bool) {
+	// set directory
+	clonedRepoDir := ""
+	logger.Debug("Setting directory for ", repoInfo.RepositoryName, " to begin scanning")
+	if args.Mode == utilities.LOCAL {
+		// set directory or file to local file
+		clonedRepoDir = args.LocalScanFilePath
+		logger.Debug("Local file scan path is ", args.LocalScanFilePath)
+	} else if args.Mode == utilities.LOCALREPOS {
+		// each repository is already checked out on disk
+		clonedRepoDir = repoInfo.LocalPath
+	} else {
+		logger.Info("Cloning respository ", repoInfo.RepositoryName, " ", ref, "...")
+
+		// TODO: add support for cloning using zip for more platforms
+		if args.CloneRepoUsingZip {
+			logger.Debug("Cloning using zip")
+			clonedRepoDir = CloneRepoUsingZip(args.Mode, args.AccessToken, args.BaseURL, repoInfo, ref)
+		} else {
+			logger.Debug("Cloning using git clone")
+			clonedRepoDir = CloneRepo(args, repoInfo, ref)
+		}
+		if clonedRepoDir == "" {
+			return nil, scanner.FileScanResults{}, false
+		}
+	}
+
+	// scan LOC for the directory
+	logger.Info("Scanning ", clonedRepoDir, "...")
+	filePaths := scanner.WalkDirectory(clonedRepoDir, args.IgnorePatterns)
+	fileScanResultsArr := []scanner.FileScanResults{}
+	for _, filePath := range filePaths {
+		fileScanResultsArr = append(fileScanResultsArr, scanner.ScanFile(filePath))
+	}
+
+	logger.Debug("Calculating total LOC for ", repoInfo.RepositoryName)
+
+	// sort and calculate total LOC
+	fileScanResultsArr = report.SortFileScanResults(fileScanResultsArr)
+	repoTotalResult := report.CalculateTotalLineOfCode(fileScanResultsArr)
+
+	// clean up cloned repo after scan completes
+	if args.Mode == utilities.LOCAL || args.Mode == utilities.LOCALREPOS {
+		// do not delete the directory if we are scanning a local file or directory
+	} else {
+		// delete the cloned repo directory after scanning
+		logger.Debug("Deleting directory ", clonedRepoDir)
+		err := os.RemoveAll(clonedRepoDir)
+		if err != nil {
+			logger.Error("Failed to remove directory: ", clonedRepoDir)
+		}
+	}
+	return fileScanResultsArr, repoTotalResult, true
+}
+
+/*
+@ref Branch or tag to download, empty downloads the default branch
+*/
+func CloneRepoUsingZip(mode string, accessToken string, baseURL string, repoInfo devops.RepoInfo, ref string) string {
 	clonedRepoDir := ""
+	branch := ref
+	if branch == "" {
+		branch = repoInfo.DefaultBranch
+	}
 	if mode == utilities.GITHUB {
 		// clone repo
-		defaultBranch := github.DiscoverDefaultBranchForRepoGithub(repoInfo.OrganizationName, repoInfo.RepositoryName, accessToken)
-		zipUrl := github.CreateZipURLGithub(repoInfo.OrganizationName, repoInfo.RepositoryName, defaultBranch)
+		if branch == "" {
+			branch = github.DiscoverDefaultBranchForRepoGithub(repoInfo.OrganizationName, repoInfo.RepositoryName, accessToken)
+		}
+		zipUrl := github.CreateZipURLGithub(repoInfo.OrganizationName, repoInfo.RepositoryName, branch)
+		if github.IsInstallationTokenGithub(accessToken) {
+			zipUrl = github.CreateZipURLGithubAPI(repoInfo.OrganizationName, repoInfo.RepositoryName, branch)
+		}
 		clonedRepoDir = clone.DonwloadAndUnzip(zipUrl, repoInfo.RepositoryName, accessToken)
 	} else if mode == utilities.AZUREDEVOPS {
-		zipUrl := azuredevops.CreateZipURLAzureDevOps(repoInfo.OrganizationName, repoInfo.ProjectName, repoInfo.RepositoryName, repoInfo.DefaultBranch)
+		versionType := azuredevops.BRANCHVERSION
+		if strings.HasPrefix(branch, "refs/tags/") {
+			versionType = azuredevops.TAGVERSION
+			branch = strings.TrimPrefix(branch, "refs/tags/")
+		}
+		branch = azuredevops.TrimBranchRef(branch)
+		zipUrl := azuredevops.CreateZipURLAzureDevOps(repoInfo.OrganizationName, repoInfo.ProjectName, repoInfo.RepositoryName, branch, versionType)
 		clonedRepoDir = clone.DonwloadAndUnzip(zipUrl, repoInfo.RepositoryName, accessToken)
+		// a short ref name is tried as a branch first, then as a tag, like when cloning
+		if clonedRepoDir == "" && ref != "" && !strings.HasPrefix(ref, "refs/") {
+			logger.Debug("Branch ", ref, " not found, trying tag")
+			zipUrl = azuredevops.CreateZipURLAzureDevOps(repoInfo.OrganizationName, repoInfo.ProjectName, repoInfo.RepositoryName, ref, azuredevops.TAGVERSION)
+			clonedRepoDir = clone.DonwloadAndUnzip(zipUrl, repoInfo.RepositoryName, accessToken)
+		}
 	} else if mode == utilities.GITLAB {
-		zipUrl := gitlab.CreateZipURLGitLab(repoInfo.OrganizationName, repoInfo.RepositoryName, repoInfo.DefaultBranch)
+		zipUrl := gitlab.CreateZipURLGitLab(repoInfo.Namespace, repoInfo.Slug, branch)
 		clonedRepoDir = clone.DonwloadAndUnzip(zipUrl, repoInfo.RepositoryName, accessToken)
 	} else if mode == utilities.BITBUCKET {
-		logger.Warn("Cloning using zip is not tested for Bitbucket yet. It may not work as expected.")
-		zipUrl := bitbucket.CreateZipURLBitbucket(accessToken, repoInfo.OrganizationName, repoInfo.RepositoryName, repoInfo.DefaultBranch)
+		zipUrl := bitbucket.CreateZipURLBitbucket(repoInfo.OrganizationName, repoInfo.Slug, branch)
+		clonedRepoDir = clone.DonwloadAndUnzip(zipUrl, repoInfo.RepositoryName, accessToken)
+	} else if mode == utilities.GITEA {
+		zipUrl := gitea.CreateZipURLGitea(baseURL, repoInfo.OrganizationName, repoInfo.RepositoryName, branch)
 		clonedRepoDir = clone.DonwloadAndUnzip(zipUrl, repoInfo.RepositoryName, accessToken)
 	} else {
 		logger.Error("Mode ", mode, " is not supported for cloning using zip")
@@ -232,43 +761,164 @@ func CloneRepoUsingZip(mode string, accessToken string, repoInfo devops.RepoInfo
 	return clonedRepoDir
 }
 
-func CloneRepo(mode string, accessToken string, organization string, repoInfo devops.RepoInfo) string {
+// CreateCloneURL creates the git URL of the repository for the given mode and protocol
+func CreateCloneURL(mode string, cloneProtocol string, baseURL string, repoInfo devops.RepoInfo) string {
+	organization := repoInfo.OrganizationName
+	if mode == utilities.GIT {
+		// the URL comes straight from the repositories file
+		return repoInfo.CloneURL
+	}
+	if cloneProtocol == clone.SSH {
+		cloneRepoUrl := ""
+		if mode == utilities.GITHUB {
+			cloneRepoUrl = github.CreateSSHCloneURLGithub(organization, repoInfo.RepositoryName)
+		} else if mode == utilities.AZUREDEVOPS {
+			cloneRepoUrl = azuredevops.CreateSSHCloneURLAzureDevOps(organization, repoInfo.ProjectName, repoInfo.RepositoryName)
+		} else if mode == utilities.GITLAB {
+			cloneRepoUrl = gitlab.CreateSSHCloneURLGitLab(repoInfo.Namespace, repoInfo.Slug)
+		} else if mode == utilities.BITBUCKET {
+			cloneRepoUrl = bitbucket.CreateSSHCloneURLBitbucket(organization, repoInfo.Slug)
+		} else if mode == utilities.GITEA {
+			cloneRepoUrl = gitea.CreateSSHCloneURLGitea(baseURL, organization, repoInfo.RepositoryName)
+		} else {
+			logger.Error("Mode ", mode, " is not supported for cloning using SSH")
+		}
+		return cloneRepoUrl
+	}
+
 	cloneRepoUrl := ""
-	clonedRepoDir := ""
 	if mode == utilities.GITHUB {
-		cloneRepoUrl = github.CreateCloneURLGithub(accessToken, organization, repoInfo.RepositoryName)
-		clonedRepoDir = clone.CloneRepo(cloneRepoUrl, accessToken, repoInfo.RepositoryName)
-
+		cloneRepoUrl = github.CreateCloneURLGithub(organization, repoInfo.RepositoryName)
 	} else if mode == utilities.AZUREDEVOPS {
-		cloneRepoUrl = azuredevops.CreateCloneURLAzureDevOps(accessToken, organization, repoInfo.ProjectName, repoInfo.RepositoryName)
-		clonedRepoDir = clone.CloneRepoAzureDevOps(cloneRepoUrl, accessToken, repoInfo.RepositoryName)
+		cloneRepoUrl = azuredevops.CreateCloneURLAzureDevOps(organization, repoInfo.ProjectName, repoInfo.RepositoryName)
 	} else if mode == utilities.GITLAB {
-		cloneRepoUrl = gitlab.CreateCloneURLGitLab(accessToken, organization, repoInfo.RepositoryName)
-		clonedRepoDir = clone.CloneRepo(cloneRepoUrl, accessToken, repoInfo.RepositoryName)
+		cloneRepoUrl = gitlab.CreateCloneURLGitLab(repoInfo.Namespace, repoInfo.Slug)
 	} else if mode == utilities.BITBUCKET {
-		cloneRepoUrl = bitbucket.CreateCloneURLBitbucket(accessToken, organization, repoInfo.RepositoryName)
-		clonedRepoDir = clone.CloneRepo(cloneRepoUrl, accessToken, repoInfo.RepositoryName)
+		cloneRepoUrl = bitbucket.CreateCloneURLBitbucket(organization, repoInfo.Slug)
+	} else if mode == utilities.GITEA {
+		cloneRepoUrl = gitea.CreateCloneURLGitea(baseURL, organization, repoInfo.RepositoryName)
 	} else {
 		logger.Error("Mode ", mode, " is not supported")
 	}
+	return cloneRepoUrl
+}
+
+// CreateCloneAuth creates the authentication for cloning the URL. SSH URLs use the SSH options, even when listed in a repositories file
+func CreateCloneAuth(args utilities.CLIArgs, cloneRepoUrl string) transport.AuthMethod {
+	if clone.IsSSHURL(cloneRepoUrl) {
+		auth, err := clone.CreateSSHAuth(args.SSHOptions)
+		if err != nil {
+			logger.Error("Failed to set up SSH authentication")
+			logger.LogStackTraceAndExit(err)
+		}
+		return auth
+	}
+	// the access token is passed as basic authentication instead of being embedded in the URL
+	if args.Mode == utilities.GITHUB {
+		return github.CreateCloneAuthGithub(args.AccessToken)
+	} else if args.Mode == utilities.AZUREDEVOPS {
+		return azuredevops.CreateCloneAuthAzureDevOps(args.AccessToken)
+	} else if args.Mode == utilities.GITLAB {
+		return gitlab.CreateCloneAuthGitLab(args.AccessToken)
+	} else if args.Mode == utilities.BITBUCKET {
+		return bitbucket.CreateCloneAuthBitbucket(args.AccessToken)
+	} else if args.Mode == utilities.GITEA {
+		return gitea.CreateCloneAuthGitea(args.AccessToken)
+	}
+	// for Git, any credentials are part of the URL in the repositories file
+	return nil
+}
+
+/*
+@ref Branch or tag to clone, empty clones the default branch
+*/
+func CloneRepo(args utilities.CLIArgs, repoInfo devops.RepoInfo, ref string) string {
+	cloneRepoUrl := CreateCloneURL(args.Mode, args.CloneProtocol, args.BaseURL, repoInfo)
+	if cloneRepoUrl == "" {
+		return ""
+	}
+	if args.Mode == utilities.GIT && ref == "" {
+		// the ref from the repositories file is stored as the default branch
+		ref = repoInfo.DefaultBranch
+	}
+
+	auth := CreateCloneAuth(args, cloneRepoUrl)
+	clonedRepoDir := ""
+	if args.Mode == utilities.AZUREDEVOPS {
+		clonedRepoDir = clone.CloneRepoAzureDevOps(cloneRepoUrl, auth, ref, repoInfo.RepositoryName)
+	} else {
+		clonedRepoDir = clone.CloneRepo(cloneRepoUrl, auth, ref, repoInfo.RepositoryName)
+	}
 	return clonedRepoDir
 }
 
-func DiscoverRepositories(mode string, accessToken string, organization string) []devops.RepoInfo {
+// CreateGithubAppTokenSource creates the GitHub App token source, or returns nil when not authenticating as a GitHub App
+func CreateGithubAppTokenSource(args utilities.CLIArgs) *github.AppTokenSource {
+	if args.GithubAppId == "" {
+		return nil
+	}
+	accountName := ""
+	if len(args.Organizations) > 0 {
+		accountName = args.Organizations[0]
+	}
+	githubApp, err := github.NewAppTokenSource(args.GithubAppId, args.GithubAppInstallationId, args.GithubAppPrivateKeyPath, accountName)
+	if err != nil {
+		logger.Error("Failed to authenticate as GitHub App ", args.GithubAppId)
+		logger.LogStackTraceAndExit(err)
+	}
+	return githubApp
+}
+
+func DiscoverRepositories(args utilities.CLIArgs) []devops.RepoInfo {
+	mode := args.Mode
+	accessToken := args.AccessToken
 	repositoryInfoArr := []devops.RepoInfo{}
-	if mode == utilities.LOCAL {
+	if args.RepositoriesInventoryPath != "" {
+		// use the explicit list of repositories instead of discovering them
+		entries, err := inventory.ReadInventory(args.RepositoriesInventoryPath)
+		if err != nil {
+			logger.LogStackTraceAndExit(err)
+		}
+		for _, entry := range entries {
+			if entry.Provider != "" && entry.Provider != mode {
+				logger.Warn("Repository ", entry.RepositoryName, " was discovered using ", entry.Provider, " but is scanned using ", mode)
+			}
+			repositoryInfoArr = append(repositoryInfoArr, entry.RepoInfo())
+		}
+	} else if mode == utilities.LOCAL {
 		repositoryInfo := devops.NewRepoInfo("local-org", "", "local", "")
 		repositoryInfoArr = append(repositoryInfoArr, repositoryInfo)
-	} else if mode == utilities.GITHUB {
-		repositoryInfoArr = github.DiscoverReposGithub(organization, accessToken)
-	} else if mode == utilities.AZUREDEVOPS {
-		repositoryInfoArr = azuredevops.DiscoverReposAzureDevOps(organization, accessToken)
-	} else if mode == utilities.GITLAB {
-		repositoryInfoArr = gitlab.DiscoverReposGitlab(organization, accessToken)
-	} else if mode == utilities.BITBUCKET {
-		repositoryInfoArr = bitbucket.DiscoverReposBitbucket(organization, accessToken)
+	} else if mode == utilities.LOCALREPOS {
+		repositoryInfoArr = local.DiscoverReposLocal(args.LocalScanFilePath, args.LocalGitReposOnly)
+	} else if mode == utilities.GIT {
+		repositoryInfoArr = gitlist.DiscoverReposFromFile(args.RepositoriesFilePath)
+	} else if mode == utilities.GITHUB && args.GithubAppId != "" {
+		// the installation lists every repository the app can access, optionally restricted to the given organizations
+		for _, repoInfo := range github.DiscoverReposGithubApp(accessToken) {
+			if len(args.Organizations) == 0 || slices.ContainsFunc(args.Organizations, func(organization string) bool {
+				return strings.EqualFold(organization, repoInfo.OrganizationName)
+			}) {
+				repositoryInfoArr = append(repositoryInfoArr, repoInfo)
+			}
+		}
 	} else {
-		logger.LogStackTraceAndExit("Mode " + mode + " is not supported")
+		// discover each organization separately and combine the results
+		for _, organization := range args.Organizations {
+			logger.Debug("Discovering repositories in ", organization)
+			if mode == utilities.GITHUB {
+				repositoryInfoArr = append(repositoryInfoArr, github.DiscoverReposGithubWithBackend(organization, accessToken, args.GithubDiscovery)...)
+			} else if mode == utilities.AZUREDEVOPS {
+				repositoryInfoArr = append(repositoryInfoArr, azuredevops.DiscoverReposAzureDevOps(organization, accessToken, args.IncludeProjects, args.ExcludeProjects)...)
+			} else if mode == utilities.GITLAB {
+				repositoryInfoArr = append(repositoryInfoArr, gitlab.DiscoverReposGitlab(organization, accessToken)...)
+			} else if mode == utilities.BITBUCKET {
+				repositoryInfoArr = append(repositoryInfoArr, bitbucket.DiscoverReposBitbucket(organization, accessToken)...)
+			} else if mode == utilities.GITEA {
+				repositoryInfoArr = append(repositoryInfoArr, gitea.DiscoverReposGitea(args.BaseURL, organization, accessToken)...)
+			} else {
+				logger.LogStackTraceAndExit("Mode " + mode + " is not supported")
+			}
+		}
 	}
 	return repositoryInfoArr
 }
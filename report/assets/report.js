@@ -0,0 +1,349 @@
+(function () {
+  "use strict";
+
+  var report = JSON.parse(document.getElementById("report-data").textContent);
+  var palette = ["#0969da", "#1a7f37", "#bf3989", "#9a6700", "#8250df", "#cf222e", "#1b7c83", "#bc4c00", "#57606a", "#6639ba"];
+  var kindLabels = { root: "Organizations", organization: "Repositories", repository: "Directories and files", directory: "Directories and files" };
+  var SVGNS = "http://www.w3.org/2000/svg";
+
+  // build the drill-down tree: all results -> organization -> repository -> directory -> file
+  function newNode(name, kind, parent) {
+    return { name: name, kind: kind, parent: parent, children: {}, files: 0, blank: 0, comment: 0, code: 0, languages: {} };
+  }
+
+  function child(node, name, kind) {
+    if (!node.children[name]) {
+      node.children[name] = newNode(name, kind, node);
+    }
+    return node.children[name];
+  }
+
+  // addCounts adds the lines to the node and every node above it
+  function addCounts(node, counts, language) {
+    for (; node; node = node.parent) {
+      node.files += counts.files;
+      node.blank += counts.blank;
+      node.comment += counts.comment;
+      node.code += counts.code;
+      if (language) {
+        node.languages[language] = (node.languages[language] || 0) + counts.code;
+      }
+    }
+  }
+
+  // commonDirectory returns the number of leading directories shared by every path, e.g. the clone directory
+  function commonDirectory(paths) {
+    if (paths.length === 0) {
+      return 0;
+    }
+    var common = paths[0].slice(0, -1);
+    paths.forEach(function (segments) {
+      var length = 0;
+      while (length < common.length && length < segments.length - 1 && common[length] === segments[length]) {
+        length++;
+      }
+      common = common.slice(0, length);
+    });
+    return common.length;
+  }
+
+  var root = newNode("All repositories", "root", null);
+  report.repositories.forEach(function (repository) {
+    var organization = child(root, repository.organization || "(no organization)", "organization");
+    var repositoryNode = child(organization, repository.id, "repository");
+    if (repository.files.length === 0) {
+      // only the totals are known
+      addCounts(repositoryNode, repository.totals, null);
+      return;
+    }
+    var paths = repository.files.map(function (file) {
+      return file.path.split(/[\\/]+/).filter(function (segment) { return segment !== "" && segment !== "."; });
+    });
+    var skipped = commonDirectory(paths);
+    repository.files.forEach(function (file, index) {
+      var segments = paths[index].slice(skipped);
+      var node = repositoryNode;
+      for (var i = 0; i < segments.length - 1; i++) {
+        node = child(node, segments[i], "directory");
+      }
+      var fileNode = child(node, segments[segments.length - 1], "file");
+      fileNode.language = file.language;
+      addCounts(fileNode, { files: 1, blank: file.blank, comment: file.comment, code: file.code }, file.language);
+    });
+  });
+
+  function childrenOf(node) {
+    return Object.keys(node.children).map(function (name) { return node.children[name]; });
+  }
+
+  function formatNumber(value) {
+    return value.toLocaleString("en-US");
+  }
+
+  function formatShare(value, total) {
+    return total > 0 ? (100 * value / total).toFixed(1) + "%" : "0.0%";
+  }
+
+  function element(tag, text, className) {
+    var created = document.createElement(tag);
+    if (text !== undefined && text !== null) {
+      created.textContent = text;
+    }
+    if (className) {
+      created.className = className;
+    }
+    return created;
+  }
+
+  function svgElement(tag, attributes) {
+    var created = document.createElementNS(SVGNS, tag);
+    Object.keys(attributes).forEach(function (name) { created.setAttribute(name, attributes[name]); });
+    return created;
+  }
+
+  // renderTable renders rows into a table sortable by clicking a column header
+  function renderTable(table, columns, rows, sortColumn, descending, onRowClick) {
+    function render() {
+      var column = columns[sortColumn];
+      var sorted = rows.slice().sort(function (a, b) {
+        var left = column.value(a), right = column.value(b);
+        var order = typeof left === "number" ? left - right : String(left).localeCompare(String(right));
+        return descending ? -order : order;
+      });
+
+      table.textContent = "";
+      var head = table.createTHead().insertRow();
+      columns.forEach(function (candidate, index) {
+        var th = element("th", candidate.title, candidate.numeric ? "number" : "");
+        if (index === sortColumn) {
+          th.setAttribute("aria-sort", descending ? "descending" : "ascending");
+        }
+        th.addEventListener("click", function () {
+          // numbers are sorted from the largest first, text alphabetically
+          descending = index === sortColumn ? !descending : !!candidate.numeric;
+          sortColumn = index;
+          render();
+        });
+        head.appendChild(th);
+      });
+
+      var body = table.createTBody();
+      sorted.forEach(function (row) {
+        var tr = body.insertRow();
+        columns.forEach(function (candidate) {
+          var value = candidate.format ? candidate.format(row) : candidate.value(row);
+          tr.appendChild(element("td", value, candidate.numeric ? "number" : ""));
+        });
+        if (onRowClick && onRowClick.enabled(row)) {
+          tr.className = "drillable";
+          tr.addEventListener("click", function () { onRowClick.click(row); });
+        }
+      });
+    }
+    render();
+  }
+
+  function renderBreadcrumb(node) {
+    var breadcrumb = document.getElementById("breadcrumb");
+    breadcrumb.textContent = "";
+    var path = [];
+    for (var current = node; current; current = current.parent) {
+      path.unshift(current);
+    }
+    path.forEach(function (current, index) {
+      if (index > 0) {
+        breadcrumb.appendChild(element("span", "/", "separator"));
+      }
+      if (current === node) {
+        breadcrumb.appendChild(element("strong", current.name));
+      } else {
+        var link = element("a", current.name);
+        link.addEventListener("click", function () { show(current); });
+        breadcrumb.appendChild(link);
+      }
+    });
+  }
+
+  function renderPie(node) {
+    var svg = document.getElementById("pie");
+    var legend = document.getElementById("legend");
+    svg.textContent = "";
+    legend.textContent = "";
+
+    // the largest languages, the rest is grouped as other
+    var slices = Object.keys(node.languages).map(function (language) {
+      return { name: language, value: node.languages[language] };
+    }).filter(function (slice) { return slice.value > 0; }).sort(function (a, b) { return b.value - a.value; });
+    if (slices.length > 8) {
+      var other = slices.slice(7).reduce(function (sum, slice) { return sum + slice.value; }, 0);
+      slices = slices.slice(0, 7).concat([{ name: "Other", value: other }]);
+    }
+    var total = slices.reduce(function (sum, slice) { return sum + slice.value; }, 0);
+    if (total === 0) {
+      legend.appendChild(element("li", "No languages"));
+      return;
+    }
+
+    var angle = -Math.PI / 2;
+    slices.forEach(function (slice, index) {
+      var color = palette[index % palette.length];
+      var fraction = slice.value / total;
+      var shape;
+      if (fraction >= 1) {
+        shape = svgElement("circle", { cx: 110, cy: 110, r: 100, fill: color });
+      } else {
+        var end = angle + fraction * 2 * Math.PI;
+        var path = "M110,110 L" + (110 + 100 * Math.cos(angle)) + "," + (110 + 100 * Math.sin(angle)) +
+          " A100,100 0 " + (fraction > 0.5 ? 1 : 0) + " 1 " + (110 + 100 * Math.cos(end)) + "," + (110 + 100 * Math.sin(end)) + " Z";
+        shape = svgElement("path", { d: path, fill: color, stroke: "#fff", "stroke-width": 1 });
+        angle = end;
+      }
+      var title = svgElement("title", {});
+      title.textContent = slice.name + ": " + formatNumber(slice.value) + " LOC";
+      shape.appendChild(title);
+      svg.appendChild(shape);
+
+      var item = element("li");
+      var swatch = element("span", null, "swatch");
+      swatch.style.background = color;
+      item.appendChild(swatch);
+      item.appendChild(element("span", slice.name));
+      item.appendChild(element("span", formatShare(slice.value, total), "share"));
+      legend.appendChild(item);
+    });
+  }
+
+  // worstRatio is the largest aspect ratio of a row of the squarified treemap
+  function worstRatio(row, side) {
+    var sum = 0, max = 0, min = Infinity;
+    row.forEach(function (cell) {
+      sum += cell.area;
+      max = Math.max(max, cell.area);
+      min = Math.min(min, cell.area);
+    });
+    return Math.max((side * side * max) / (sum * sum), (sum * sum) / (side * side * min));
+  }
+
+  // squarify lays the items out in the rectangle, keeping the cells as square as possible
+  function squarify(items, x, y, width, height) {
+    var total = items.reduce(function (sum, item) { return sum + item.code; }, 0);
+    var scale = (width * height) / total;
+    var rest = items.map(function (item) { return { item: item, area: item.code * scale }; });
+    var cells = [];
+    while (rest.length > 0) {
+      var side = Math.min(width, height);
+      var row = [], best = Infinity;
+      while (rest.length > 0) {
+        var candidate = row.concat([rest[0]]);
+        var ratio = worstRatio(candidate, side);
+        if (row.length > 0 && ratio > best) {
+          break;
+        }
+        row = candidate;
+        best = ratio;
+        rest.shift();
+      }
+      var rowArea = row.reduce(function (sum, cell) { return sum + cell.area; }, 0);
+      if (width >= height) {
+        var columnWidth = rowArea / height, cellY = y;
+        row.forEach(function (cell) {
+          cells.push({ item: cell.item, x: x, y: cellY, width: columnWidth, height: cell.area / columnWidth });
+          cellY += cell.area / columnWidth;
+        });
+        x += columnWidth;
+        width -= columnWidth;
+      } else {
+        var rowHeight = rowArea / width, cellX = x;
+        row.forEach(function (cell) {
+          cells.push({ item: cell.item, x: cellX, y: y, width: cell.area / rowHeight, height: rowHeight });
+          cellX += cell.area / rowHeight;
+        });
+        y += rowHeight;
+        height -= rowHeight;
+      }
+    }
+    return cells;
+  }
+
+  function renderTreemap(node) {
+    var svg = document.getElementById("treemap");
+    svg.textContent = "";
+    var items = childrenOf(node).filter(function (item) { return item.code > 0; }).sort(function (a, b) { return b.code - a.code; });
+    if (items.length === 0) {
+      var empty = svgElement("text", { x: 400, y: 210, "text-anchor": "middle", fill: "#656d76" });
+      empty.textContent = "No lines of code";
+      svg.appendChild(empty);
+      return;
+    }
+    squarify(items, 0, 0, 800, 420).forEach(function (cell, index) {
+      var drillable = cell.item.kind !== "file";
+      var rect = svgElement("rect", {
+        x: cell.x, y: cell.y, width: Math.max(cell.width, 0), height: Math.max(cell.height, 0),
+        fill: palette[index % palette.length], "class": drillable ? "" : "leaf"
+      });
+      var title = svgElement("title", {});
+      title.textContent = cell.item.name + ": " + formatNumber(cell.item.code) + " LOC (" + formatShare(cell.item.code, node.code) + ")";
+      rect.appendChild(title);
+      if (drillable) {
+        rect.addEventListener("click", function () { show(cell.item); });
+      }
+      svg.appendChild(rect);
+      if (cell.width > 60 && cell.height > 20) {
+        var label = svgElement("text", { x: cell.x + 4, y: cell.y + 15 });
+        var maxCharacters = Math.floor((cell.width - 8) / 7);
+        label.textContent = cell.item.name.length > maxCharacters ? cell.item.name.slice(0, Math.max(maxCharacters - 1, 1)) + "…" : cell.item.name;
+        svg.appendChild(label);
+      }
+    });
+  }
+
+  function renderDrilldownTable(node) {
+    document.getElementById("drilldown-title").textContent = kindLabels[node.kind] + " (" + formatNumber(Object.keys(node.children).length) + ")";
+    var columns = [
+      { title: "Name", value: function (row) { return row.kind === "directory" ? row.name + "/" : row.name; } },
+      { title: "Language", value: function (row) { return row.language || ""; } },
+      { title: "Files", numeric: true, value: function (row) { return row.files; }, format: function (row) { return formatNumber(row.files); } },
+      { title: "Blank", numeric: true, value: function (row) { return row.blank; }, format: function (row) { return formatNumber(row.blank); } },
+      { title: "Comment", numeric: true, value: function (row) { return row.comment; }, format: function (row) { return formatNumber(row.comment); } },
+      { title: "Code", numeric: true, value: function (row) { return row.code; }, format: function (row) { return formatNumber(row.code); } },
+      { title: "Share", numeric: true, value: function (row) { return row.code; }, format: function (row) { return formatShare(row.code, node.code); } }
+    ];
+    if (node.kind === "root" || node.kind === "organization") {
+      // only files have a language
+      columns.splice(1, 1);
+    }
+    var codeColumn = columns.length - 2;
+    renderTable(document.getElementById("drilldown"), columns, childrenOf(node), codeColumn, true, {
+      enabled: function (row) { return row.kind !== "file"; },
+      click: function (row) { show(row); }
+    });
+  }
+
+  function renderFailedTable() {
+    var table = document.getElementById("failed");
+    if (!table) {
+      return;
+    }
+    renderTable(table, [
+      { title: "Organization", value: function (row) { return row.organization; } },
+      { title: "Repository", value: function (row) { return row.id; } },
+      { title: "Name", value: function (row) { return row.name; } },
+      { title: "Reason", value: function (row) { return row.reason; } }
+    ], report.failedRepositories, 1, false, null);
+  }
+
+  function show(node) {
+    renderBreadcrumb(node);
+    renderPie(node);
+    renderTreemap(node);
+    renderDrilldownTable(node);
+  }
+
+  // skip the organization level when there is only one organization
+  var start = root;
+  if (Object.keys(root.children).length === 1) {
+    start = childrenOf(root)[0];
+  }
+  show(start);
+  renderFailedTable();
+})();
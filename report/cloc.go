@@ -0,0 +1,276 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"go-cloc/logger"
+	"go-cloc/scanner"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Formats mirroring the output of the Perl cloc, so scripts written for cloc can read the results of go-cloc
+const (
+	CLOCJSON string = "cloc-json"
+	CLOCYAML string = "cloc-yaml"
+	CLOCXML  string = "cloc-xml"
+	// CLOCCSV mirrors cloc --csv --by-file
+	CLOCCSV string = "cloc-csv"
+)
+
+// clocLanguageNames maps the languages whose name differs in cloc, e.g. cloc counts C and C++ headers together
+var clocLanguageNames = map[string]string{
+	"Abap":          "ABAP",
+	"Apex":          "Apex Class",
+	"C Header":      "C/C++ Header",
+	"C++ Header":    "C/C++ Header",
+	"Golang":        "Go",
+	"Oracle PL/SQL": "PL/SQL",
+	"Scss":          "SCSS",
+	"Terraform":     "HCL",
+	"Vue":           "Vuejs Component",
+}
+
+// ClocHeader is the header block of the cloc formats
+type ClocHeader struct {
+	ClocURL        string    `json:"cloc_url" xml:"cloc_url"`
+	ClocVersion    string    `json:"cloc_version" xml:"cloc_version"`
+	ElapsedSeconds clocFloat `json:"elapsed_seconds" xml:"elapsed_seconds"`
+	NumFiles       int       `json:"n_files" xml:"n_files"`
+	NumLines       int       `json:"n_lines" xml:"n_lines"`
+	FilesPerSecond clocFloat `json:"files_per_second" xml:"files_per_second"`
+	LinesPerSecond clocFloat `json:"lines_per_second" xml:"lines_per_second"`
+}
+
+// clocFloat is written without an exponent, as cloc does
+type clocFloat float64
+
+func (value clocFloat) String() string {
+	return strconv.FormatFloat(float64(value), 'f', -1, 64)
+}
+
+func (value clocFloat) MarshalJSON() ([]byte, error) {
+	return []byte(value.String()), nil
+}
+
+func (value clocFloat) MarshalText() ([]byte, error) {
+	return []byte(value.String()), nil
+}
+
+// clocLanguage is the line counts of a language, in the order cloc writes them
+type clocLanguage struct {
+	FileCount         int `json:"nFiles"`
+	BlankLineCount    int `json:"blank"`
+	CommentsLineCount int `json:"comment"`
+	CodeLineCount     int `json:"code"`
+}
+
+// clocSum is the SUM of every language, in the order cloc writes it
+type clocSum struct {
+	BlankLineCount    int `json:"blank"`
+	CommentsLineCount int `json:"comment"`
+	CodeLineCount     int `json:"code"`
+	FileCount         int `json:"nFiles"`
+}
+
+// ClocLanguageName returns the name cloc uses for a language
+func ClocLanguageName(language string) string {
+	if clocName, ok := clocLanguageNames[language]; ok {
+		return clocName
+	}
+	return language
+}
+
+// CalculateClocLanguageCounts sums the results by file per cloc language, sorted by CodeLineCount in descending order
+func CalculateClocLanguageCounts(fileScanResultsArr []scanner.FileScanResults) []LanguageCounts {
+	renamed := make([]scanner.FileScanResults, len(fileScanResultsArr))
+	for index, results := range fileScanResultsArr {
+		results.Language = ClocLanguageName(results.Language)
+		renamed[index] = results
+	}
+	return CalculateLanguageCounts(renamed)
+}
+
+// CreateClocHeader creates the header block, with the speed of the scan
+func CreateClocHeader(version string, elapsedSeconds float64, totals LineCounts) ClocHeader {
+	header := ClocHeader{
+		ClocURL:        "go-cloc",
+		ClocVersion:    version,
+		ElapsedSeconds: clocFloat(elapsedSeconds),
+		NumFiles:       totals.FileCount,
+		NumLines:       totals.BlankLineCount + totals.CommentsLineCount + totals.CodeLineCount,
+	}
+	if elapsedSeconds > 0 {
+		header.FilesPerSecond = clocFloat(float64(header.NumFiles) / elapsedSeconds)
+		header.LinesPerSecond = clocFloat(float64(header.NumLines) / elapsedSeconds)
+	}
+	return header
+}
+
+// sumLanguageCounts sums the line counts of every language
+func sumLanguageCounts(languageCounts []LanguageCounts) LineCounts {
+	totals := LineCounts{}
+	for _, counts := range languageCounts {
+		totals.FileCount += counts.FileCount
+		totals.BlankLineCount += counts.BlankLineCount
+		totals.CommentsLineCount += counts.CommentsLineCount
+		totals.CodeLineCount += counts.CodeLineCount
+	}
+	return totals
+}
+
+// WriteClocJsonTo writes the results like cloc --json: the header, one key per language, then SUM
+func WriteClocJsonTo(writer io.Writer, header ClocHeader, languageCounts []LanguageCounts) error {
+	totals := sumLanguageCounts(languageCounts)
+	// a map would sort the keys, so the object is written key by key to keep the order of cloc
+	var buffer bytes.Buffer
+	writeMember := func(key string, value any) error {
+		encodedKey, _ := json.Marshal(key)
+		encodedValue, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if buffer.Len() > 0 {
+			buffer.WriteString(",")
+		}
+		buffer.Write(encodedKey)
+		buffer.WriteString(":")
+		buffer.Write(encodedValue)
+		return nil
+	}
+	if err := writeMember("header", header); err != nil {
+		return err
+	}
+	for _, counts := range languageCounts {
+		if err := writeMember(counts.Language, clocLanguage(counts.LineCounts)); err != nil {
+			return err
+		}
+	}
+	if err := writeMember("SUM", clocSum{totals.BlankLineCount, totals.CommentsLineCount, totals.CodeLineCount, totals.FileCount}); err != nil {
+		return err
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, []byte("{"+buffer.String()+"}"), "", "  "); err != nil {
+		logger.Error("Error encoding json: ", err)
+		return err
+	}
+	_, err := io.WriteString(writer, logger.Redact(indented.String())+"\n")
+	return err
+}
+
+// WriteClocYamlTo writes the results like cloc --yaml: the header, one key per language, then SUM
+func WriteClocYamlTo(writer io.Writer, header ClocHeader, languageCounts []LanguageCounts) error {
+	totals := sumLanguageCounts(languageCounts)
+	// the document is built as nodes to keep the order of cloc
+	document := &yaml.Node{Kind: yaml.MappingNode}
+	addMapping := func(parent *yaml.Node, key string) *yaml.Node {
+		value := &yaml.Node{Kind: yaml.MappingNode}
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+		return value
+	}
+	addScalar := func(parent *yaml.Node, key string, value string) {
+		parent.Content = append(parent.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &yaml.Node{Kind: yaml.ScalarNode, Value: value})
+	}
+
+	headerNode := addMapping(document, "header")
+	addScalar(headerNode, "cloc_url", header.ClocURL)
+	addScalar(headerNode, "cloc_version", header.ClocVersion)
+	addScalar(headerNode, "elapsed_seconds", header.ElapsedSeconds.String())
+	addScalar(headerNode, "n_files", strconv.Itoa(header.NumFiles))
+	addScalar(headerNode, "n_lines", strconv.Itoa(header.NumLines))
+	addScalar(headerNode, "files_per_second", header.FilesPerSecond.String())
+	addScalar(headerNode, "lines_per_second", header.LinesPerSecond.String())
+	for _, counts := range languageCounts {
+		languageNode := addMapping(document, counts.Language)
+		addScalar(languageNode, "nFiles", strconv.Itoa(counts.FileCount))
+		addScalar(languageNode, "blank", strconv.Itoa(counts.BlankLineCount))
+		addScalar(languageNode, "comment", strconv.Itoa(counts.CommentsLineCount))
+		addScalar(languageNode, "code", strconv.Itoa(counts.CodeLineCount))
+	}
+	sumNode := addMapping(document, "SUM")
+	addScalar(sumNode, "blank", strconv.Itoa(totals.BlankLineCount))
+	addScalar(sumNode, "comment", strconv.Itoa(totals.CommentsLineCount))
+	addScalar(sumNode, "code", strconv.Itoa(totals.CodeLineCount))
+	addScalar(sumNode, "nFiles", strconv.Itoa(totals.FileCount))
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(document); err != nil {
+		logger.Error("Error encoding yaml: ", err)
+		return err
+	}
+	encoder.Close()
+	_, err := io.WriteString(writer, "---\n# "+header.ClocURL+"\n"+logger.Redact(buffer.String()))
+	return err
+}
+
+// clocXmlResults is the document written by cloc --xml
+type clocXmlResults struct {
+	XMLName   xml.Name         `xml:"results"`
+	Header    ClocHeader       `xml:"header"`
+	Languages clocXmlLanguages `xml:"languages"`
+}
+
+type clocXmlLanguages struct {
+	Languages []clocXmlLanguage `xml:"language"`
+	Total     clocXmlTotal      `xml:"total"`
+}
+
+type clocXmlLanguage struct {
+	Name              string `xml:"name,attr"`
+	FileCount         int    `xml:"files_count,attr"`
+	BlankLineCount    int    `xml:"blank,attr"`
+	CommentsLineCount int    `xml:"comment,attr"`
+	CodeLineCount     int    `xml:"code,attr"`
+}
+
+type clocXmlTotal struct {
+	FileCount         int `xml:"sum_files,attr"`
+	BlankLineCount    int `xml:"blank,attr"`
+	CommentsLineCount int `xml:"comment,attr"`
+	CodeLineCount     int `xml:"code,attr"`
+}
+
+// WriteClocXmlTo writes the results like cloc --xml
+func WriteClocXmlTo(writer io.Writer, header ClocHeader, languageCounts []LanguageCounts) error {
+	totals := sumLanguageCounts(languageCounts)
+	results := clocXmlResults{Header: header}
+	for _, counts := range languageCounts {
+		results.Languages.Languages = append(results.Languages.Languages, clocXmlLanguage{counts.Language, counts.FileCount, counts.BlankLineCount, counts.CommentsLineCount, counts.CodeLineCount})
+	}
+	results.Languages.Total = clocXmlTotal(totals)
+
+	data, err := xml.MarshalIndent(results, "", "  ")
+	if err != nil {
+		logger.Error("Error encoding xml: ", err)
+		return err
+	}
+	_, err = io.WriteString(writer, xml.Header+logger.Redact(string(data))+"\n")
+	return err
+}
+
+// ConvertFileResultsIntoClocRecords creates the records of cloc --csv --by-file, sorted by CodeLineCount in descending order.
+// Like cloc, the last column of the header holds the version and the speed of the scan
+func ConvertFileResultsIntoClocRecords(header ClocHeader, fileScanResultsArr []scanner.FileScanResults) [][]string {
+	summary := fmt.Sprintf("%s v %s  T=%.2f s (%.1f files/s, %.1f lines/s)", header.ClocURL, header.ClocVersion, float64(header.ElapsedSeconds), float64(header.FilesPerSecond), float64(header.LinesPerSecond))
+	records := [][]string{
+		{"language", "filename", "blank", "comment", "code", summary},
+	}
+
+	sorted := SortFileScanResults(append([]scanner.FileScanResults{}, fileScanResultsArr...))
+	totals := LineCounts{}
+	for _, results := range sorted {
+		language := ClocLanguageName(languageOf(results))
+		records = append(records, []string{language, results.FilePath, strconv.Itoa(results.BlankLineCount), strconv.Itoa(results.CommentsLineCount), strconv.Itoa(results.CodeLineCount)})
+		totals.add(results)
+	}
+	// Append SUM Row
+	records = append(records, []string{"SUM", "", strconv.Itoa(totals.BlankLineCount), strconv.Itoa(totals.CommentsLineCount), strconv.Itoa(totals.CodeLineCount)})
+	return records
+}
@@ -0,0 +1,101 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"go-cloc/scanner"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+var clocTestFiles = []scanner.FileScanResults{
+	{FilePath: "a/main.go", Language: "Golang", BlankLineCount: 2, CommentsLineCount: 1, CodeLineCount: 10},
+	{FilePath: "a/util.h", Language: "C Header", BlankLineCount: 1, CodeLineCount: 3},
+	{FilePath: "a/app.hpp", Language: "C++ Header", CommentsLineCount: 2, CodeLineCount: 4},
+}
+
+func Test_report_CalculateClocLanguageCounts(t *testing.T) {
+	languageCounts := CalculateClocLanguageCounts(clocTestFiles)
+
+	// Assert
+	assert.Equal(t, []LanguageCounts{
+		{Language: "Go", LineCounts: LineCounts{FileCount: 1, BlankLineCount: 2, CommentsLineCount: 1, CodeLineCount: 10}},
+		{Language: "C/C++ Header", LineCounts: LineCounts{FileCount: 2, BlankLineCount: 1, CommentsLineCount: 2, CodeLineCount: 7}},
+	}, languageCounts)
+}
+
+func Test_report_CreateClocHeader(t *testing.T) {
+	header := CreateClocHeader("1.0.0", 2, LineCounts{FileCount: 3, BlankLineCount: 3, CommentsLineCount: 3, CodeLineCount: 14})
+
+	// Assert
+	assert.Equal(t, 20, header.NumLines)
+	assert.Equal(t, clocFloat(1.5), header.FilesPerSecond)
+	assert.Equal(t, clocFloat(10), header.LinesPerSecond)
+}
+
+func Test_report_WriteClocJsonTo(t *testing.T) {
+	var buffer bytes.Buffer
+	header := CreateClocHeader("1.0.0", 0.5, LineCounts{FileCount: 3})
+
+	err := WriteClocJsonTo(&buffer, header, CalculateClocLanguageCounts(clocTestFiles))
+
+	// Assert
+	assert.NoError(t, err)
+	document := map[string]map[string]any{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &document))
+	assert.Equal(t, float64(6), document["header"]["files_per_second"])
+	assert.Equal(t, map[string]any{"nFiles": float64(1), "blank": float64(2), "comment": float64(1), "code": float64(10)}, document["Go"])
+	assert.Equal(t, map[string]any{"nFiles": float64(3), "blank": float64(3), "comment": float64(3), "code": float64(17)}, document["SUM"])
+	// like cloc, the header comes first and SUM last
+	assert.Less(t, bytes.Index(buffer.Bytes(), []byte(`"header"`)), bytes.Index(buffer.Bytes(), []byte(`"Go"`)))
+	assert.Less(t, bytes.Index(buffer.Bytes(), []byte(`"C/C++ Header"`)), bytes.Index(buffer.Bytes(), []byte(`"SUM"`)))
+}
+
+func Test_report_WriteClocYamlTo(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := WriteClocYamlTo(&buffer, CreateClocHeader("1.0.0", 1, LineCounts{}), CalculateClocLanguageCounts(clocTestFiles))
+
+	// Assert
+	assert.NoError(t, err)
+	document := map[string]map[string]any{}
+	assert.NoError(t, yaml.Unmarshal(buffer.Bytes(), &document))
+	assert.Equal(t, "1.0.0", document["header"]["cloc_version"])
+	assert.Equal(t, map[string]any{"nFiles": 2, "blank": 1, "comment": 2, "code": 7}, document["C/C++ Header"])
+	assert.Equal(t, 17, document["SUM"]["code"])
+}
+
+func Test_report_WriteClocXmlTo(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := WriteClocXmlTo(&buffer, CreateClocHeader("1.0.0", 1, LineCounts{}), CalculateClocLanguageCounts(clocTestFiles))
+
+	// Assert
+	assert.NoError(t, err)
+	results := clocXmlResults{}
+	assert.NoError(t, xml.Unmarshal(buffer.Bytes(), &results))
+	assert.Equal(t, []clocXmlLanguage{
+		{Name: "Go", FileCount: 1, BlankLineCount: 2, CommentsLineCount: 1, CodeLineCount: 10},
+		{Name: "C/C++ Header", FileCount: 2, BlankLineCount: 1, CommentsLineCount: 2, CodeLineCount: 7},
+	}, results.Languages.Languages)
+	assert.Equal(t, clocXmlTotal{FileCount: 3, BlankLineCount: 3, CommentsLineCount: 3, CodeLineCount: 17}, results.Languages.Total)
+}
+
+func Test_report_ConvertFileResultsIntoClocRecords(t *testing.T) {
+	records := ConvertFileResultsIntoClocRecords(CreateClocHeader("1.0.0", 2, LineCounts{FileCount: 3, CodeLineCount: 17}), clocTestFiles)
+
+	// Assert
+	assert.Equal(t, [][]string{
+		{"language", "filename", "blank", "comment", "code", "go-cloc v 1.0.0  T=2.00 s (1.5 files/s, 8.5 lines/s)"},
+		{"Go", "a/main.go", "2", "1", "10"},
+		{"C/C++ Header", "a/app.hpp", "0", "2", "4"},
+		{"C/C++ Header", "a/util.h", "1", "0", "3"},
+		{"SUM", "", "3", "3", "17"},
+	}, records)
+	// the results by file keep their order
+	assert.Equal(t, "a/main.go", clocTestFiles[0].FilePath)
+	assert.Equal(t, "a/util.h", clocTestFiles[1].FilePath)
+}
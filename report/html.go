@@ -0,0 +1,82 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	"go-cloc/logger"
+	"html/template"
+	"io"
+	"strconv"
+	"time"
+)
+
+// HTML is the format of the self-contained HTML report
+const HTML string = "html"
+
+// the stylesheet and the script are embedded in the report, so it opens offline
+//
+//go:embed assets/report.html assets/report.css assets/report.js
+var htmlAssets embed.FS
+
+var htmlTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{"number": formatNumber}).ParseFS(htmlAssets, "assets/report.html"))
+
+// htmlReportData is rendered by the HTML template, the report is embedded as JSON for the charts and tables
+type htmlReportData struct {
+	Title       string
+	GeneratedAt string
+	Duration    string
+	Run         RunMetadata
+	Report      JsonReport
+	Style       template.CSS
+	Script      template.JS
+}
+
+// WriteHtmlTo writes a single HTML file with sortable tables drilling down from organizations to files,
+// a pie chart of the languages, a treemap of the LOC and the failed repositories
+func WriteHtmlTo(writer io.Writer, metadata RunMetadata, repoTotals []RepoTotal, failedRepos []FailedRepo) error {
+	style, err := htmlAssets.ReadFile("assets/report.css")
+	if err != nil {
+		return err
+	}
+	script, err := htmlAssets.ReadFile("assets/report.js")
+	if err != nil {
+		return err
+	}
+
+	data := htmlReportData{
+		Title:       "Lines of code",
+		GeneratedAt: time.Now().Format("2006-01-02 15:04 MST"),
+		Run:         metadata,
+		Report:      CreateJsonReport(metadata, repoTotals, failedRepos),
+		Style:       template.CSS(style),
+		Script:      template.JS(script),
+	}
+	if metadata.Organization != "" {
+		data.Title += " of " + metadata.Organization
+	}
+	// reports rendered again from existing results do not know how long the scan took
+	if !metadata.StartedAt.IsZero() {
+		data.Duration = time.Duration(metadata.DurationSeconds * float64(time.Second)).Round(time.Second).String()
+	}
+
+	var buffer bytes.Buffer
+	if err := htmlTemplate.Execute(&buffer, data); err != nil {
+		logger.Error("Error rendering html: ", err)
+		return err
+	}
+	_, err = io.WriteString(writer, logger.Redact(buffer.String()))
+	return err
+}
+
+// formatNumber formats a number with thousands separators, e.g. 1,234,567
+func formatNumber(value int) string {
+	digits := strconv.Itoa(value)
+	sign := ""
+	if value < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	for index := len(digits) - 3; index > 0; index -= 3 {
+		digits = digits[:index] + "," + digits[index:]
+	}
+	return sign + digits
+}
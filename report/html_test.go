@@ -0,0 +1,41 @@
+package report
+
+import (
+	"bytes"
+	"go-cloc/scanner"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_report_WriteHtmlTo(t *testing.T) {
+	var buffer bytes.Buffer
+	repoTotals := []RepoTotal{
+		{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 1234, Files: []scanner.FileScanResults{
+			{FilePath: "a/main.go", Language: "Golang", CodeLineCount: 1234},
+		}},
+	}
+	failedRepos := []FailedRepo{{RepositoryId: "org-b", OrganizationName: "org", RepositoryName: "</script><script>alert(1)</script>", Reason: "failed to clone"}}
+
+	err := WriteHtmlTo(&buffer, RunMetadata{Tool: "go-cloc", Version: "1.0.0", Organization: "org"}, repoTotals, failedRepos)
+
+	// Assert
+	assert.NoError(t, err)
+	html := buffer.String()
+	assert.Contains(t, html, "<title>Lines of code of org</title>")
+	assert.Contains(t, html, `<span class="value">1,234</span>`)
+	// the assets are embedded
+	assert.Contains(t, html, "function squarify(")
+	assert.Contains(t, html, ".treemap rect")
+	assert.Contains(t, html, `"path":"a/main.go"`)
+	// the data cannot close the script element
+	assert.NotContains(t, html, "<script>alert(1)")
+}
+
+func Test_report_formatNumber(t *testing.T) {
+	// Assert
+	assert.Equal(t, "0", formatNumber(0))
+	assert.Equal(t, "999", formatNumber(999))
+	assert.Equal(t, "1,000", formatNumber(1000))
+	assert.Equal(t, "-1,234,567", formatNumber(-1234567))
+}
@@ -0,0 +1,232 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-cloc/logger"
+	"go-cloc/scanner"
+	"io"
+	"sort"
+	"time"
+)
+
+// Formats of the combined report
+const (
+	CSV  string = "csv"
+	JSON string = "json"
+)
+
+// Formats lists every supported --format
+var Formats = []string{CSV, JSON, CLOCJSON, CLOCYAML, CLOCXML, CLOCCSV, HTML, MARKDOWN}
+
+// JSONSCHEMAVERSION is the version of the JSON report. It is increased whenever a field is renamed or removed
+const JSONSCHEMAVERSION int = 1
+
+// UNKNOWNLANGUAGE groups the files without a language
+const UNKNOWNLANGUAGE string = "unknown"
+
+// KeepsFileResults returns whether the format reports the results by file, which then have to be kept in memory
+func KeepsFileResults(format string) bool {
+	return format != CSV
+}
+
+// RunMetadata describes the scan that produced a report
+type RunMetadata struct {
+	Tool            string    `json:"tool"`
+	Version         string    `json:"version"`
+	Mode            string    `json:"mode"`
+	Organization    string    `json:"organization"`
+	StartedAt       time.Time `json:"startedAt"`
+	FinishedAt      time.Time `json:"finishedAt"`
+	DurationSeconds float64   `json:"durationSeconds"`
+	// NumRepos is the number of repositories selected for scanning, including the failed ones
+	NumRepos        int `json:"repositoryCount"`
+	NumScannedRepos int `json:"scannedRepositoryCount"`
+	NumFailedRepos  int `json:"failedRepositoryCount"`
+}
+
+// LineCounts are the number of files and lines of a repository, a language or the whole scan
+type LineCounts struct {
+	FileCount         int `json:"files"`
+	BlankLineCount    int `json:"blank"`
+	CommentsLineCount int `json:"comment"`
+	CodeLineCount     int `json:"code"`
+}
+
+// add adds the lines of a file
+func (counts *LineCounts) add(results scanner.FileScanResults) {
+	counts.FileCount++
+	counts.BlankLineCount += results.BlankLineCount
+	counts.CommentsLineCount += results.CommentsLineCount
+	counts.CodeLineCount += results.CodeLineCount
+}
+
+// LanguageCounts are the line counts of a single language
+type LanguageCounts struct {
+	Language string `json:"language"`
+	LineCounts
+}
+
+// FileCounts are the line counts of a single file
+type FileCounts struct {
+	FilePath          string `json:"path"`
+	Language          string `json:"language"`
+	BlankLineCount    int    `json:"blank"`
+	CommentsLineCount int    `json:"comment"`
+	CodeLineCount     int    `json:"code"`
+}
+
+// RepoReport is the detail of a single repository
+type RepoReport struct {
+	RepositoryId     string           `json:"id"`
+	OrganizationName string           `json:"organization"`
+	ProjectName      string           `json:"project,omitempty"`
+	Branch           string           `json:"branch,omitempty"`
+	Totals           LineCounts       `json:"totals"`
+	Languages        []LanguageCounts `json:"languages"`
+	Files            []FileCounts     `json:"files"`
+}
+
+// FailedRepoReport is a repository that could not be scanned
+type FailedRepoReport struct {
+	RepositoryId     string `json:"id"`
+	OrganizationName string `json:"organization"`
+	RepositoryName   string `json:"name"`
+	Reason           string `json:"reason"`
+}
+
+// JsonReport is the document written by --format json
+type JsonReport struct {
+	SchemaVersion      int                `json:"schemaVersion"`
+	Run                RunMetadata        `json:"run"`
+	Totals             LineCounts         `json:"totals"`
+	Languages          []LanguageCounts   `json:"languages"`
+	Repositories       []RepoReport       `json:"repositories"`
+	FailedRepositories []FailedRepoReport `json:"failedRepositories"`
+}
+
+// CreateJsonReport creates the JSON report from the repo totals, which must include their results by file
+func CreateJsonReport(metadata RunMetadata, repoTotals []RepoTotal, failedRepos []FailedRepo) JsonReport {
+	jsonReport := JsonReport{
+		SchemaVersion:      JSONSCHEMAVERSION,
+		Run:                metadata,
+		Languages:          []LanguageCounts{},
+		Repositories:       []RepoReport{},
+		FailedRepositories: []FailedRepoReport{},
+	}
+
+	allFiles := []scanner.FileScanResults{}
+	for _, repoTotal := range repoTotals {
+		repoReport := RepoReport{
+			RepositoryId:     repoTotal.RepositoryId,
+			OrganizationName: repoTotal.OrganizationName,
+			ProjectName:      repoTotal.ProjectName,
+			Branch:           repoTotal.Branch,
+			Languages:        CalculateLanguageCounts(repoTotal.Files),
+			Files:            []FileCounts{},
+		}
+		for _, results := range repoTotal.Files {
+			repoReport.Totals.add(results)
+			repoReport.Files = append(repoReport.Files, FileCounts{
+				FilePath:          results.FilePath,
+				Language:          languageOf(results),
+				BlankLineCount:    results.BlankLineCount,
+				CommentsLineCount: results.CommentsLineCount,
+				CodeLineCount:     results.CodeLineCount,
+			})
+		}
+		if len(repoTotal.Files) == 0 {
+			// only the totals are known, e.g. when read from a combined report without the results by file
+			repoReport.Totals = LineCounts{BlankLineCount: repoTotal.BlankLineCount, CommentsLineCount: repoTotal.CommentsLineCount, CodeLineCount: repoTotal.CodeLineCount}
+		}
+		jsonReport.Totals.FileCount += repoReport.Totals.FileCount
+		jsonReport.Totals.BlankLineCount += repoReport.Totals.BlankLineCount
+		jsonReport.Totals.CommentsLineCount += repoReport.Totals.CommentsLineCount
+		jsonReport.Totals.CodeLineCount += repoReport.Totals.CodeLineCount
+		jsonReport.Repositories = append(jsonReport.Repositories, repoReport)
+		allFiles = append(allFiles, repoTotal.Files...)
+	}
+	jsonReport.Languages = CalculateLanguageCounts(allFiles)
+
+	for _, failedRepo := range failedRepos {
+		jsonReport.FailedRepositories = append(jsonReport.FailedRepositories, FailedRepoReport(failedRepo))
+	}
+	return jsonReport
+}
+
+// CalculateLanguageCounts sums the results by file per language, sorted by CodeLineCount in descending order
+func CalculateLanguageCounts(fileScanResultsArr []scanner.FileScanResults) []LanguageCounts {
+	countsByLanguage := map[string]*LanguageCounts{}
+	for _, results := range fileScanResultsArr {
+		language := languageOf(results)
+		if _, ok := countsByLanguage[language]; !ok {
+			countsByLanguage[language] = &LanguageCounts{Language: language}
+		}
+		countsByLanguage[language].add(results)
+	}
+
+	languageCounts := []LanguageCounts{}
+	for _, counts := range countsByLanguage {
+		languageCounts = append(languageCounts, *counts)
+	}
+	// Sort by CodeLineCount desc, then by language to keep the output stable
+	sort.Slice(languageCounts, func(a, b int) bool {
+		if languageCounts[a].CodeLineCount == languageCounts[b].CodeLineCount {
+			return languageCounts[a].Language < languageCounts[b].Language
+		}
+		return languageCounts[a].CodeLineCount > languageCounts[b].CodeLineCount
+	})
+	return languageCounts
+}
+
+// languageOf returns the language of a file, or UNKNOWNLANGUAGE
+func languageOf(results scanner.FileScanResults) string {
+	if results.Language == "" {
+		return UNKNOWNLANGUAGE
+	}
+	return results.Language
+}
+
+// WriteFormattedReportTo writes the report in a format other than csv to a writer, e.g. standard output
+func WriteFormattedReportTo(writer io.Writer, format string, metadata RunMetadata, repoTotals []RepoTotal, failedRepos []FailedRepo, options FormatOptions) error {
+	if format == JSON {
+		return WriteJsonTo(writer, CreateJsonReport(metadata, repoTotals, failedRepos))
+	} else if format == HTML {
+		return WriteHtmlTo(writer, metadata, repoTotals, failedRepos)
+	} else if format == MARKDOWN {
+		return WriteMarkdownTo(writer, metadata, repoTotals, failedRepos, options)
+	}
+
+	// the cloc formats only report the files, regardless of the repository they belong to
+	allFiles := []scanner.FileScanResults{}
+	for _, repoTotal := range repoTotals {
+		allFiles = append(allFiles, repoTotal.Files...)
+	}
+	languageCounts := CalculateClocLanguageCounts(allFiles)
+	header := CreateClocHeader(metadata.Version, metadata.DurationSeconds, sumLanguageCounts(languageCounts))
+	switch format {
+	case CLOCJSON:
+		return WriteClocJsonTo(writer, header, languageCounts)
+	case CLOCYAML:
+		return WriteClocYamlTo(writer, header, languageCounts)
+	case CLOCXML:
+		return WriteClocXmlTo(writer, header, languageCounts)
+	case CLOCCSV:
+		return WriteCsvTo(writer, ConvertFileResultsIntoClocRecords(header, allFiles))
+	}
+	return fmt.Errorf("unsupported format %s", format)
+}
+
+// WriteJsonTo writes the value as indented JSON to a writer, masking any registered secret
+func WriteJsonTo(writer io.Writer, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		logger.Error("Error encoding json: ", err)
+		return err
+	}
+	_, err = io.WriteString(writer, logger.Redact(string(data))+"\n")
+	if err != nil {
+		logger.Error("Error writing json: ", err)
+	}
+	return err
+}
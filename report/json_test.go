@@ -0,0 +1,59 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-cloc/scanner"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_report_CreateJsonReport(t *testing.T) {
+	repoTotals := []RepoTotal{
+		{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 15, Files: []scanner.FileScanResults{
+			{FilePath: "a/main.go", Language: "Golang", BlankLineCount: 2, CommentsLineCount: 1, CodeLineCount: 10},
+			{FilePath: "a/app.js", Language: "JavaScript", BlankLineCount: 1, CodeLineCount: 5},
+		}},
+		{RepositoryId: "org-team-b", OrganizationName: "org", ProjectName: "team", Branch: "main", CodeLineCount: 20, Files: []scanner.FileScanResults{
+			{FilePath: "b/server.go", Language: "Golang", CommentsLineCount: 3, CodeLineCount: 20},
+		}},
+	}
+	failedRepos := []FailedRepo{{RepositoryId: "org-c", OrganizationName: "org", RepositoryName: "c", Reason: "failed to clone"}}
+
+	jsonReport := CreateJsonReport(RunMetadata{Tool: "go-cloc", NumRepos: 3, NumScannedRepos: 2, NumFailedRepos: 1}, repoTotals, failedRepos)
+
+	// Assert
+	assert.Equal(t, JSONSCHEMAVERSION, jsonReport.SchemaVersion)
+	assert.Equal(t, LineCounts{FileCount: 3, BlankLineCount: 3, CommentsLineCount: 4, CodeLineCount: 35}, jsonReport.Totals)
+	assert.Equal(t, []LanguageCounts{
+		{Language: "Golang", LineCounts: LineCounts{FileCount: 2, BlankLineCount: 2, CommentsLineCount: 4, CodeLineCount: 30}},
+		{Language: "JavaScript", LineCounts: LineCounts{FileCount: 1, BlankLineCount: 1, CodeLineCount: 5}},
+	}, jsonReport.Languages)
+	assert.Len(t, jsonReport.Repositories, 2)
+	assert.Equal(t, LineCounts{FileCount: 2, BlankLineCount: 3, CommentsLineCount: 1, CodeLineCount: 15}, jsonReport.Repositories[0].Totals)
+	assert.Equal(t, FileCounts{FilePath: "b/server.go", Language: "Golang", CommentsLineCount: 3, CodeLineCount: 20}, jsonReport.Repositories[1].Files[0])
+	assert.Equal(t, []FailedRepoReport{{RepositoryId: "org-c", OrganizationName: "org", RepositoryName: "c", Reason: "failed to clone"}}, jsonReport.FailedRepositories)
+}
+
+func Test_report_WriteJsonTo(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := WriteJsonTo(&buffer, CreateJsonReport(RunMetadata{Tool: "go-cloc"}, []RepoTotal{}, nil))
+
+	// Assert
+	assert.NoError(t, err)
+	document := map[string]any{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &document))
+	assert.Equal(t, float64(JSONSCHEMAVERSION), document["schemaVersion"])
+	// empty lists are written as [] rather than null
+	assert.Equal(t, []any{}, document["repositories"])
+	assert.Equal(t, []any{}, document["failedRepositories"])
+}
+
+func Test_report_CalculateLanguageCounts_unknown_language(t *testing.T) {
+	languageCounts := CalculateLanguageCounts([]scanner.FileScanResults{{FilePath: "Makefile", CodeLineCount: 4}})
+
+	// Assert
+	assert.Equal(t, []LanguageCounts{{Language: UNKNOWNLANGUAGE, LineCounts: LineCounts{FileCount: 1, CodeLineCount: 4}}}, languageCounts)
+}
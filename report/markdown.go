@@ -0,0 +1,178 @@
+package report
+
+import (
+	"fmt"
+	"go-cloc/logger"
+	"go-cloc/scanner"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MARKDOWN is the format of the summary for pull request comments and CI job summaries
+const MARKDOWN string = "markdown"
+
+// DEFAULTTOPFILES is the number of largest files listed by default
+const DEFAULTTOPFILES int = 10
+
+// FormatOptions are the options of the formats that summarize the results
+type FormatOptions struct {
+	// TopFiles is the number of largest files to list, 0 leaves the list out
+	TopFiles int
+	// Baseline are the repo totals of a previous scan to compare with, nil leaves the comparison out
+	Baseline []RepoTotal
+}
+
+// markdownTable builds a Markdown table, numeric columns are aligned to the right
+type markdownTable struct {
+	builder *strings.Builder
+}
+
+func newMarkdownTable(builder *strings.Builder, header []string, numeric []bool) markdownTable {
+	table := markdownTable{builder}
+	table.row(header...)
+	separators := make([]string, len(header))
+	for index := range header {
+		separators[index] = "---"
+		if numeric[index] {
+			separators[index] = "--:"
+		}
+	}
+	builder.WriteString("|" + strings.Join(separators, "|") + "|\n")
+	return table
+}
+
+func (table markdownTable) row(cells ...string) {
+	escaped := make([]string, len(cells))
+	for index, cell := range cells {
+		escaped[index] = escapeMarkdownCell(cell)
+	}
+	table.builder.WriteString("| " + strings.Join(escaped, " | ") + " |\n")
+}
+
+// escapeMarkdownCell keeps a value within its cell, e.g. a file name containing a pipe
+func escapeMarkdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// formatDelta formats a change in LOC with its sign, e.g. +1,234
+func formatDelta(delta int) string {
+	if delta > 0 {
+		return "+" + formatNumber(delta)
+	}
+	return formatNumber(delta)
+}
+
+// WriteMarkdownTo writes a summary with the totals, a table per language and per repository, the largest files,
+// the failed repositories and, when a baseline is given, the changes in LOC per repository
+func WriteMarkdownTo(writer io.Writer, metadata RunMetadata, repoTotals []RepoTotal, failedRepos []FailedRepo, options FormatOptions) error {
+	jsonReport := CreateJsonReport(metadata, repoTotals, failedRepos)
+	builder := &strings.Builder{}
+
+	title := "Lines of code"
+	if metadata.Organization != "" {
+		title += " of " + metadata.Organization
+	}
+	builder.WriteString("## " + title + "\n\n")
+
+	// totals
+	table := newMarkdownTable(builder, []string{"", "Files", "Blank", "Comment", "Code"}, []bool{false, true, true, true, true})
+	totals := jsonReport.Totals
+	table.row("**Total**", formatNumber(totals.FileCount), formatNumber(totals.BlankLineCount), formatNumber(totals.CommentsLineCount), "**"+formatNumber(totals.CodeLineCount)+"**")
+	summary := []string{fmt.Sprintf("%d repositories scanned, %d failed", len(jsonReport.Repositories), len(jsonReport.FailedRepositories))}
+	if metadata.Version != "" {
+		summary = append(summary, "go-cloc "+metadata.Version)
+	}
+	if !metadata.StartedAt.IsZero() {
+		summary = append(summary, time.Duration(metadata.DurationSeconds*float64(time.Second)).Round(time.Second).String())
+	}
+	builder.WriteString("\n_" + strings.Join(summary, " · ") + "_\n")
+
+	// languages
+	if len(jsonReport.Languages) > 0 {
+		builder.WriteString("\n### Languages\n\n")
+		table = newMarkdownTable(builder, []string{"Language", "Files", "Blank", "Comment", "Code", "Share"}, []bool{false, true, true, true, true, true})
+		for _, counts := range jsonReport.Languages {
+			table.row(counts.Language, formatNumber(counts.FileCount), formatNumber(counts.BlankLineCount), formatNumber(counts.CommentsLineCount), formatNumber(counts.CodeLineCount), formatShare(counts.CodeLineCount, totals.CodeLineCount))
+		}
+	}
+
+	// repositories, largest first
+	if len(jsonReport.Repositories) > 0 {
+		builder.WriteString("\n### Repositories\n\n")
+		repositories := append([]RepoReport{}, jsonReport.Repositories...)
+		sort.SliceStable(repositories, func(a, b int) bool {
+			return repositories[a].Totals.CodeLineCount > repositories[b].Totals.CodeLineCount
+		})
+		table = newMarkdownTable(builder, []string{"Organization", "Repository", "Files", "Blank", "Comment", "Code", "Share"}, []bool{false, false, true, true, true, true, true})
+		for _, repository := range repositories {
+			counts := repository.Totals
+			table.row(repository.OrganizationName, repository.RepositoryId, formatNumber(counts.FileCount), formatNumber(counts.BlankLineCount), formatNumber(counts.CommentsLineCount), formatNumber(counts.CodeLineCount), formatShare(counts.CodeLineCount, totals.CodeLineCount))
+		}
+	}
+
+	// largest files of every repository
+	if options.TopFiles > 0 && totals.FileCount > 0 {
+		type repoFile struct {
+			repositoryId string
+			results      scanner.FileScanResults
+		}
+		files := []repoFile{}
+		for _, repoTotal := range repoTotals {
+			for _, results := range repoTotal.Files {
+				files = append(files, repoFile{repoTotal.RepositoryId, results})
+			}
+		}
+		sort.SliceStable(files, func(a, b int) bool {
+			return files[a].results.CodeLineCount > files[b].results.CodeLineCount
+		})
+		if len(files) > options.TopFiles {
+			files = files[:options.TopFiles]
+		}
+		builder.WriteString("\n### Largest files\n\n")
+		table = newMarkdownTable(builder, []string{"Repository", "File", "Language", "Code"}, []bool{false, false, false, true})
+		for _, file := range files {
+			table.row(file.repositoryId, "`"+file.results.FilePath+"`", languageOf(file.results), formatNumber(file.results.CodeLineCount))
+		}
+	}
+
+	// changes since the baseline, the total row of the comparison is highlighted
+	if options.Baseline != nil {
+		builder.WriteString("\n### Changes since the baseline\n\n")
+		table = newMarkdownTable(builder, []string{"Organization", "Repository", "Baseline", "Current", "Delta"}, []bool{false, false, true, true, true})
+		diffRecords := ConvertRepoTotalsDiffIntoRecords(options.Baseline, repoTotals)
+		for _, record := range diffRecords[1:] {
+			baseline, _ := strconv.Atoi(record[2])
+			current, _ := strconv.Atoi(record[3])
+			delta, _ := strconv.Atoi(record[4])
+			if record[1] == "total" {
+				table.row("", "**Total**", "**"+formatNumber(baseline)+"**", "**"+formatNumber(current)+"**", "**"+formatDelta(delta)+"**")
+			} else if delta != 0 {
+				table.row(record[0], record[1], formatNumber(baseline), formatNumber(current), formatDelta(delta))
+			}
+		}
+	}
+
+	// failed repositories
+	if len(jsonReport.FailedRepositories) > 0 {
+		builder.WriteString("\n### Failed repositories\n\n")
+		table = newMarkdownTable(builder, []string{"Organization", "Repository", "Reason"}, []bool{false, false, false})
+		for _, failedRepo := range jsonReport.FailedRepositories {
+			table.row(failedRepo.OrganizationName, failedRepo.RepositoryId, failedRepo.Reason)
+		}
+	}
+
+	_, err := io.WriteString(writer, logger.Redact(builder.String()))
+	return err
+}
+
+// formatShare formats the share of a total as a percentage, e.g. 12.5%
+func formatShare(value int, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return strconv.FormatFloat(100*float64(value)/float64(total), 'f', 1, 64) + "%"
+}
@@ -0,0 +1,95 @@
+package report
+
+import (
+	"bytes"
+	"go-cloc/scanner"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var markdownTestRepoTotals = []RepoTotal{
+	{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 15, Files: []scanner.FileScanResults{
+		{FilePath: "a/main.go", Language: "Golang", BlankLineCount: 2, CommentsLineCount: 1, CodeLineCount: 10},
+		{FilePath: "a/a|b.js", Language: "JavaScript", BlankLineCount: 1, CodeLineCount: 5},
+	}},
+	{RepositoryId: "org-b", OrganizationName: "org", CodeLineCount: 1500, Files: []scanner.FileScanResults{
+		{FilePath: "b/server.go", Language: "Golang", CodeLineCount: 1500},
+	}},
+}
+
+func Test_report_WriteMarkdownTo(t *testing.T) {
+	var buffer bytes.Buffer
+	failedRepos := []FailedRepo{{RepositoryId: "org-c", OrganizationName: "org", RepositoryName: "c", Reason: "failed to clone"}}
+
+	err := WriteMarkdownTo(&buffer, RunMetadata{Organization: "org", Version: "1.0.0"}, markdownTestRepoTotals, failedRepos, FormatOptions{TopFiles: 2})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, `## Lines of code of org
+
+|  | Files | Blank | Comment | Code |
+|---|--:|--:|--:|--:|
+| **Total** | 3 | 3 | 1 | **1,515** |
+
+_2 repositories scanned, 1 failed · go-cloc 1.0.0_
+
+### Languages
+
+| Language | Files | Blank | Comment | Code | Share |
+|---|--:|--:|--:|--:|--:|
+| Golang | 2 | 2 | 1 | 1,510 | 99.7% |
+| JavaScript | 1 | 1 | 0 | 5 | 0.3% |
+
+### Repositories
+
+| Organization | Repository | Files | Blank | Comment | Code | Share |
+|---|---|--:|--:|--:|--:|--:|
+| org | org-b | 1 | 0 | 0 | 1,500 | 99.0% |
+| org | org-a | 2 | 3 | 1 | 15 | 1.0% |
+
+### Largest files
+
+| Repository | File | Language | Code |
+|---|---|---|--:|
+| org-b | `+"`b/server.go`"+` | Golang | 1,500 |
+| org-a | `+"`a/main.go`"+` | Golang | 10 |
+
+### Failed repositories
+
+| Organization | Repository | Reason |
+|---|---|---|
+| org | org-c | failed to clone |
+`, buffer.String())
+}
+
+func Test_report_WriteMarkdownTo_baseline(t *testing.T) {
+	var buffer bytes.Buffer
+	baseline := []RepoTotal{
+		{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 15},
+		{RepositoryId: "org-b", OrganizationName: "org", CodeLineCount: 1000},
+		{RepositoryId: "org-d", OrganizationName: "org", CodeLineCount: 40},
+	}
+
+	err := WriteMarkdownTo(&buffer, RunMetadata{}, markdownTestRepoTotals, nil, FormatOptions{TopFiles: 0, Baseline: baseline})
+
+	// Assert
+	assert.NoError(t, err)
+	// unchanged repositories are left out
+	assert.Contains(t, buffer.String(), `### Changes since the baseline
+
+| Organization | Repository | Baseline | Current | Delta |
+|---|---|--:|--:|--:|
+| org | org-b | 1,000 | 1,500 | +500 |
+| org | org-d | 40 | 0 | -40 |
+|  | **Total** | **1,055** | **1,515** | **+460** |
+`)
+	assert.NotContains(t, buffer.String(), "### Largest files")
+	assert.NotContains(t, buffer.String(), "### Failed repositories")
+}
+
+func Test_report_escapeMarkdownCell(t *testing.T) {
+	// Assert
+	assert.Equal(t, `a\|b.js`, escapeMarkdownCell("a|b.js"))
+	assert.Equal(t, "two lines", escapeMarkdownCell("two\nlines"))
+}
@@ -4,14 +4,39 @@ import (
 	"encoding/csv"
 	"go-cloc/logger"
 	"go-cloc/scanner"
+	"io"
 	"os"
 	"sort"
 	"strconv"
 )
 
 type RepoTotal struct {
+	RepositoryId     string
+	OrganizationName string
+	ProjectName      string
+	// Branch is the branch or tag that was scanned, empty for the default branch
+	Branch            string
+	CodeLineCount     int
+	BlankLineCount    int
+	CommentsLineCount int
+	// Files are the results by file, only kept for the formats reporting them, see KeepsFileResults
+	Files []scanner.FileScanResults
+}
+
+// BranchTotal is the total LOC of a single branch matching --branches, whether one or several branches matched
+type BranchTotal struct {
 	RepositoryId  string
+	Branch        string
 	CodeLineCount int
+	Selected      bool
+}
+
+// FailedRepo is a repository that could not be scanned, and why
+type FailedRepo struct {
+	RepositoryId     string
+	OrganizationName string
+	RepositoryName   string
+	Reason           string
 }
 
 // SortFileScanResults sorts the file scan results by CodeLineCount in descending order
@@ -73,11 +98,16 @@ func WriteCsv(outputFilePath string, records [][]string) error {
 	}
 	defer f.Close()
 
-	w := csv.NewWriter(f)
+	return WriteCsvTo(f, records)
+}
+
+// WriteCsvTo writes the records as CSV to a writer, e.g. standard output
+func WriteCsvTo(writer io.Writer, records [][]string) error {
+	w := csv.NewWriter(writer)
 	defer w.Flush()
 
 	for _, row := range records {
-		err = w.Write(row)
+		err := w.Write(redactRecord(row))
 		if err != nil {
 			logger.Error("Error writing to csv: ", err)
 			return err
@@ -86,6 +116,15 @@ func WriteCsv(outputFilePath string, records [][]string) error {
 	return nil
 }
 
+// redactRecord masks any registered secret in the record before it is written
+func redactRecord(record []string) []string {
+	redacted := make([]string, len(record))
+	for index, value := range record {
+		redacted[index] = logger.Redact(value)
+	}
+	return redacted
+}
+
 // PrintCsv prints the records to the console, useful for debugging
 func PrintCsv(records [][]string) {
 	for _, row := range records {
@@ -100,20 +139,100 @@ func PrintCsv(records [][]string) {
 	}
 }
 
+// ConvertRepoTotalsIntoRecords creates the combined report records.
+// The organization is the last column, so the repository and LOC columns keep their position from before organizations were reported
 func ConvertRepoTotalsIntoRecords(repoTotals []RepoTotal) [][]string {
 	// Create CSV information
 	records := [][]string{
-		{"repository", "lineOfCodeCount"},
+		{"repository", "lineOfCodeCount", "organization"},
 	}
 	sum := 0
 	for _, repoResult := range repoTotals {
-		row := []string{repoResult.RepositoryId, strconv.Itoa(repoResult.CodeLineCount)}
+		row := []string{repoResult.RepositoryId, strconv.Itoa(repoResult.CodeLineCount), repoResult.OrganizationName}
 		records = append(records, row)
 		// keep running total
 		sum += repoResult.CodeLineCount
 	}
 	// Create total row
-	totalRow := []string{"total", strconv.Itoa(sum)}
+	totalRow := []string{"total", strconv.Itoa(sum), ""}
 	records = append(records, totalRow)
 	return records
 }
+
+// ConvertOrganizationTotalsIntoRecords rolls the repo totals up per organization, sorted by name.
+// Kept apart from the combined report, so summing its LOC column does not count any repository twice
+func ConvertOrganizationTotalsIntoRecords(repoTotals []RepoTotal) [][]string {
+	organizationTotals := map[string]int{}
+	organizationNames := []string{}
+	for _, repoResult := range repoTotals {
+		if _, ok := organizationTotals[repoResult.OrganizationName]; !ok {
+			organizationNames = append(organizationNames, repoResult.OrganizationName)
+		}
+		organizationTotals[repoResult.OrganizationName] += repoResult.CodeLineCount
+	}
+	sort.Strings(organizationNames)
+
+	// Create CSV information
+	records := [][]string{
+		{"organization", "lineOfCodeCount"},
+	}
+	sum := 0
+	for _, organizationName := range organizationNames {
+		records = append(records, []string{organizationName, strconv.Itoa(organizationTotals[organizationName])})
+		sum += organizationTotals[organizationName]
+	}
+	// Create total row
+	records = append(records, []string{"total", strconv.Itoa(sum)})
+	return records
+}
+
+// ConvertProjectTotalsIntoRecords rolls the repo totals up per project, e.g. Azure DevOps projects or GitLab subgroups.
+// Repositories without a project are rolled up under their organization
+func ConvertProjectTotalsIntoRecords(repoTotals []RepoTotal) [][]string {
+	projectTotals := map[string]int{}
+	projectNames := []string{}
+	for _, repoResult := range repoTotals {
+		projectName := repoResult.OrganizationName
+		if repoResult.ProjectName != "" {
+			projectName += "/" + repoResult.ProjectName
+		}
+		if _, ok := projectTotals[projectName]; !ok {
+			projectNames = append(projectNames, projectName)
+		}
+		projectTotals[projectName] += repoResult.CodeLineCount
+	}
+
+	// Sort by CodeLineCount desc, then by name to keep the output stable
+	sort.Slice(projectNames, func(a, b int) bool {
+		if projectTotals[projectNames[a]] == projectTotals[projectNames[b]] {
+			return projectNames[a] < projectNames[b]
+		}
+		return projectTotals[projectNames[a]] > projectTotals[projectNames[b]]
+	})
+
+	// Create CSV information
+	records := [][]string{
+		{"project", "lineOfCodeCount"},
+	}
+	sum := 0
+	for _, projectName := range projectNames {
+		records = append(records, []string{projectName, strconv.Itoa(projectTotals[projectName])})
+		sum += projectTotals[projectName]
+	}
+	// Create total row
+	records = append(records, []string{"total", strconv.Itoa(sum)})
+	return records
+}
+
+// ConvertBranchTotalsIntoRecords creates records listing the LOC of every scanned branch and which branch was selected per repository
+func ConvertBranchTotalsIntoRecords(branchTotals []BranchTotal) [][]string {
+	// Create CSV information
+	records := [][]string{
+		{"repository", "branch", "lineOfCodeCount", "selected"},
+	}
+	for _, branchTotal := range branchTotals {
+		row := []string{branchTotal.RepositoryId, branchTotal.Branch, strconv.Itoa(branchTotal.CodeLineCount), strconv.FormatBool(branchTotal.Selected)}
+		records = append(records, row)
+	}
+	return records
+}
@@ -0,0 +1,51 @@
+package report
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_report_ConvertProjectTotalsIntoRecords(t *testing.T) {
+	repoTotals := []RepoTotal{
+		{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 5},
+		{RepositoryId: "org-team-b", OrganizationName: "org", ProjectName: "team", CodeLineCount: 10},
+		{RepositoryId: "org-team-c", OrganizationName: "org", ProjectName: "team", CodeLineCount: 20},
+	}
+
+	records := ConvertProjectTotalsIntoRecords(repoTotals)
+
+	// Assert
+	assert.Equal(t, [][]string{
+		{"project", "lineOfCodeCount"},
+		{"org/team", "30"},
+		{"org", "5"},
+		{"total", "35"},
+	}, records)
+}
+
+func Test_report_ConvertRepoTotalsIntoRecords_multiple_organizations(t *testing.T) {
+	repoTotals := []RepoTotal{
+		{RepositoryId: "org2-a", OrganizationName: "org2", CodeLineCount: 20},
+		{RepositoryId: "org1-b", OrganizationName: "org1", CodeLineCount: 10},
+		{RepositoryId: "org2-c", OrganizationName: "org2", CodeLineCount: 5},
+	}
+
+	records := ConvertRepoTotalsIntoRecords(repoTotals)
+	organizationRecords := ConvertOrganizationTotalsIntoRecords(repoTotals)
+
+	// Assert
+	assert.Equal(t, [][]string{
+		{"repository", "lineOfCodeCount", "organization"},
+		{"org2-a", "20", "org2"},
+		{"org1-b", "10", "org1"},
+		{"org2-c", "5", "org2"},
+		{"total", "35", ""},
+	}, records)
+	assert.Equal(t, [][]string{
+		{"organization", "lineOfCodeCount"},
+		{"org1", "10"},
+		{"org2", "25"},
+		{"total", "35"},
+	}, organizationRecords)
+}
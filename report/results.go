@@ -0,0 +1,260 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"go-cloc/scanner"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// COMBINEDREPORTFILENAME is the combined report written to the results directory by a scan
+const COMBINEDREPORTFILENAME string = "AAA-combined-total-lines.csv"
+
+// ESTIMATEDREPORTFILENAME is the combined report written to the results directory by --estimate
+const ESTIMATEDREPORTFILENAME string = "AAA-estimated-total-lines.csv"
+
+// FAILEDREPORTFILENAME lists the repositories that could not be scanned, written to the results directory by a scan
+const FAILEDREPORTFILENAME string = "AAA-failed-repositories.csv"
+
+// readCsv reads every record of a CSV file
+func readCsv(path string) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", path)
+	}
+	return records, nil
+}
+
+// ReadRepoTotals reads the repo totals of a combined report, so existing results can be rendered again or compared.
+// The path can be the combined report itself or the results directory containing it, falling back to the estimated report
+// of --estimate. The total row is skipped
+func ReadRepoTotals(path string) ([]RepoTotal, error) {
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if fileInfo.IsDir() {
+		resultsDirectoryPath := path
+		path = filepath.Join(resultsDirectoryPath, COMBINEDREPORTFILENAME)
+		estimatedPath := filepath.Join(resultsDirectoryPath, ESTIMATEDREPORTFILENAME)
+		if _, err := os.Stat(path); err != nil {
+			if _, err := os.Stat(estimatedPath); err == nil {
+				path = estimatedPath
+			}
+		}
+	}
+
+	records, err := readCsv(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// find the columns by name, estimates use their own LOC column
+	organizationColumn, repositoryColumn, codeColumn := -1, -1, -1
+	for index, column := range records[0] {
+		switch column {
+		case "organization":
+			organizationColumn = index
+		case "repository":
+			repositoryColumn = index
+		case "lineOfCodeCount", "estimatedLineOfCodeCount":
+			codeColumn = index
+		}
+	}
+	if repositoryColumn == -1 || codeColumn == -1 {
+		return nil, fmt.Errorf("%s is not a combined report, the columns repository and lineOfCodeCount are required", path)
+	}
+
+	repoTotals := []RepoTotal{}
+	for _, record := range records[1:] {
+		repositoryId := record[repositoryColumn]
+		if repositoryId == "total" {
+			continue
+		}
+		codeLineCount, err := strconv.Atoi(record[codeColumn])
+		if err != nil {
+			return nil, fmt.Errorf("invalid LOC %q for %s in %s", record[codeColumn], repositoryId, path)
+		}
+		repoTotal := RepoTotal{RepositoryId: repositoryId, CodeLineCount: codeLineCount}
+		if organizationColumn != -1 {
+			repoTotal.OrganizationName = record[organizationColumn]
+		}
+		repoTotals = append(repoTotals, repoTotal)
+	}
+	return repoTotals, nil
+}
+
+// ReadRepoResults reads the repo totals of a results directory with the results by file of every repository,
+// then the repositories that failed. The path can be the combined report or the results directory containing it.
+// Results by file and failed repositories missing from the directory are skipped, e.g. for results written with --estimate
+func ReadRepoResults(path string) ([]RepoTotal, []FailedRepo, error) {
+	repoTotals, err := ReadRepoTotals(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	resultsDirectoryPath := path
+	if fileInfo, _ := os.Stat(path); !fileInfo.IsDir() {
+		resultsDirectoryPath = filepath.Dir(path)
+	}
+
+	for index := range repoTotals {
+		fileResultsPath := filepath.Join(resultsDirectoryPath, repoTotals[index].RepositoryId+".csv")
+		if _, err := os.Stat(fileResultsPath); err != nil {
+			continue
+		}
+		fileScanResultsArr, err := ReadFileResults(fileResultsPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		repoTotals[index].Files = fileScanResultsArr
+		totalResults := CalculateTotalLineOfCode(fileScanResultsArr)
+		repoTotals[index].BlankLineCount = totalResults.BlankLineCount
+		repoTotals[index].CommentsLineCount = totalResults.CommentsLineCount
+	}
+
+	failedRepos := []FailedRepo{}
+	failedReportPath := filepath.Join(resultsDirectoryPath, FAILEDREPORTFILENAME)
+	if _, err := os.Stat(failedReportPath); err == nil {
+		failedRepos, err = ReadFailedRepos(failedReportPath)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return repoTotals, failedRepos, nil
+}
+
+// ReadFileResults reads the results by file of a repository, as written by ConvertFileResultsIntoRecords.
+// The language is looked up from the extension of each file, since it is not part of the results
+func ReadFileResults(path string) ([]scanner.FileScanResults, error) {
+	records, err := readCsv(path)
+	if err != nil {
+		return nil, err
+	}
+	fileScanResultsArr := []scanner.FileScanResults{}
+	for _, record := range records[1:] {
+		if len(record) < 4 {
+			return nil, fmt.Errorf("%s is not a results by file, expected the columns filePath, blank, comment and code", path)
+		}
+		if record[0] == "total" {
+			continue
+		}
+		counts := [3]int{}
+		for index := range counts {
+			counts[index], err = strconv.Atoi(record[index+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid line count %q for %s in %s", record[index+1], record[0], path)
+			}
+		}
+		language, _, _ := scanner.LookupByExtension(scanner.ParseFileSuffix(record[0]))
+		fileScanResultsArr = append(fileScanResultsArr, scanner.FileScanResults{
+			FilePath:          record[0],
+			BlankLineCount:    counts[0],
+			CommentsLineCount: counts[1],
+			CodeLineCount:     counts[2],
+			TotalLines:        counts[0] + counts[1] + counts[2],
+			Language:          language,
+		})
+	}
+	return fileScanResultsArr, nil
+}
+
+// ConvertFailedReposIntoRecords creates records listing the repositories that could not be scanned, and why
+func ConvertFailedReposIntoRecords(failedRepos []FailedRepo) [][]string {
+	// Create CSV information
+	records := [][]string{
+		{"organization", "repository", "name", "reason"},
+	}
+	for _, failedRepo := range failedRepos {
+		records = append(records, []string{failedRepo.OrganizationName, failedRepo.RepositoryId, failedRepo.RepositoryName, failedRepo.Reason})
+	}
+	return records
+}
+
+// ReadFailedRepos reads the repositories that could not be scanned, as written by ConvertFailedReposIntoRecords
+func ReadFailedRepos(path string) ([]FailedRepo, error) {
+	records, err := readCsv(path)
+	if err != nil {
+		return nil, err
+	}
+	failedRepos := []FailedRepo{}
+	for _, record := range records[1:] {
+		if len(record) < 4 {
+			return nil, fmt.Errorf("%s is not a list of failed repositories, expected the columns organization, repository, name and reason", path)
+		}
+		failedRepos = append(failedRepos, FailedRepo{OrganizationName: record[0], RepositoryId: record[1], RepositoryName: record[2], Reason: record[3]})
+	}
+	return failedRepos, nil
+}
+
+// ConvertRepoTotalsDiffIntoRecords compares the LOC of every repository with a baseline, sorted by the largest change.
+// Repositories are matched on their id only, since baselines written before the organization column have no organization.
+// Repositories missing from either side are counted as 0 LOC on that side
+func ConvertRepoTotalsDiffIntoRecords(baseline []RepoTotal, current []RepoTotal) [][]string {
+	type repoDiff struct {
+		organizationName string
+		repositoryId     string
+		baseline         int
+		current          int
+	}
+	diffs := map[string]*repoDiff{}
+	keys := []string{}
+	getDiff := func(repoTotal RepoTotal) *repoDiff {
+		key := repoTotal.RepositoryId
+		if _, ok := diffs[key]; !ok {
+			diffs[key] = &repoDiff{repositoryId: repoTotal.RepositoryId}
+			keys = append(keys, key)
+		}
+		if repoTotal.OrganizationName != "" {
+			diffs[key].organizationName = repoTotal.OrganizationName
+		}
+		return diffs[key]
+	}
+	for _, repoTotal := range baseline {
+		getDiff(repoTotal).baseline += repoTotal.CodeLineCount
+	}
+	for _, repoTotal := range current {
+		getDiff(repoTotal).current += repoTotal.CodeLineCount
+	}
+
+	// Sort by the absolute delta desc, then by name to keep the output stable
+	delta := func(key string) int {
+		difference := diffs[key].current - diffs[key].baseline
+		if difference < 0 {
+			return -difference
+		}
+		return difference
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if delta(keys[a]) == delta(keys[b]) {
+			return keys[a] < keys[b]
+		}
+		return delta(keys[a]) > delta(keys[b])
+	})
+
+	// Create CSV information
+	records := [][]string{
+		{"organization", "repository", "baselineLineOfCodeCount", "lineOfCodeCount", "delta"},
+	}
+	baselineSum, currentSum := 0, 0
+	for _, key := range keys {
+		diff := diffs[key]
+		row := []string{diff.organizationName, diff.repositoryId, strconv.Itoa(diff.baseline), strconv.Itoa(diff.current), strconv.Itoa(diff.current - diff.baseline)}
+		records = append(records, row)
+		baselineSum += diff.baseline
+		currentSum += diff.current
+	}
+	// Create total row
+	records = append(records, []string{"", "total", strconv.Itoa(baselineSum), strconv.Itoa(currentSum), strconv.Itoa(currentSum - baselineSum)})
+	return records
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"go-cloc/scanner"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_report_ReadRepoTotals(t *testing.T) {
+	resultsDirectoryPath := t.TempDir()
+	records := ConvertRepoTotalsIntoRecords([]RepoTotal{
+		{RepositoryId: "org1-a", OrganizationName: "org1", CodeLineCount: 20},
+		{RepositoryId: "org2-b", OrganizationName: "org2", CodeLineCount: 10},
+	})
+	WriteCsv(filepath.Join(resultsDirectoryPath, COMBINEDREPORTFILENAME), records)
+
+	repoTotals, err := ReadRepoTotals(resultsDirectoryPath)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []RepoTotal{
+		{RepositoryId: "org1-a", OrganizationName: "org1", CodeLineCount: 20},
+		{RepositoryId: "org2-b", OrganizationName: "org2", CodeLineCount: 10},
+	}, repoTotals)
+
+	invalidFilePath := filepath.Join(resultsDirectoryPath, "invalid.csv")
+	os.WriteFile(invalidFilePath, []byte("filePath,blank,comment,code\n"), 0644)
+	_, err = ReadRepoTotals(invalidFilePath)
+	assert.Error(t, err)
+}
+
+func Test_report_ReadRepoTotals_estimate(t *testing.T) {
+	resultsDirectoryPath := t.TempDir()
+	records := ConvertRepoTotalsIntoRecords([]RepoTotal{{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 1200}})
+	records[0][1] = "estimatedLineOfCodeCount"
+	WriteCsv(filepath.Join(resultsDirectoryPath, ESTIMATEDREPORTFILENAME), records)
+
+	repoTotals, err := ReadRepoTotals(resultsDirectoryPath)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []RepoTotal{{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 1200}}, repoTotals)
+}
+
+func Test_report_ReadRepoResults(t *testing.T) {
+	resultsDirectoryPath := t.TempDir()
+	WriteCsv(filepath.Join(resultsDirectoryPath, COMBINEDREPORTFILENAME), ConvertRepoTotalsIntoRecords([]RepoTotal{
+		{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 15},
+		{RepositoryId: "org-b", OrganizationName: "org", CodeLineCount: 7},
+	}))
+	files := []scanner.FileScanResults{
+		{FilePath: "a/main.go", BlankLineCount: 2, CommentsLineCount: 1, CodeLineCount: 10},
+		{FilePath: "a/app.js", BlankLineCount: 1, CodeLineCount: 5},
+	}
+	WriteCsv(filepath.Join(resultsDirectoryPath, "org-a.csv"), ConvertFileResultsIntoRecords(files, CalculateTotalLineOfCode(files)))
+	failedRepos := []FailedRepo{{RepositoryId: "org-c", OrganizationName: "org", RepositoryName: "c", Reason: "failed to clone"}}
+	WriteCsv(filepath.Join(resultsDirectoryPath, FAILEDREPORTFILENAME), ConvertFailedReposIntoRecords(failedRepos))
+
+	repoTotals, readFailedRepos, err := ReadRepoResults(filepath.Join(resultsDirectoryPath, COMBINEDREPORTFILENAME))
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, failedRepos, readFailedRepos)
+	assert.Equal(t, 3, repoTotals[0].BlankLineCount)
+	assert.Equal(t, []scanner.FileScanResults{
+		{FilePath: "a/main.go", BlankLineCount: 2, CommentsLineCount: 1, CodeLineCount: 10, TotalLines: 13, Language: "Golang"},
+		{FilePath: "a/app.js", BlankLineCount: 1, CodeLineCount: 5, TotalLines: 6, Language: "JavaScript"},
+	}, repoTotals[0].Files)
+	// the results by file of org-b are missing, only its total is known
+	assert.Nil(t, repoTotals[1].Files)
+	assert.Equal(t, 7, repoTotals[1].CodeLineCount)
+}
+
+func Test_report_ConvertRepoTotalsDiffIntoRecords(t *testing.T) {
+	baseline := []RepoTotal{
+		{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 100},
+		{RepositoryId: "org-b", OrganizationName: "org", CodeLineCount: 50},
+	}
+	current := []RepoTotal{
+		{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 90},
+		{RepositoryId: "org-c", OrganizationName: "org", CodeLineCount: 30},
+	}
+
+	records := ConvertRepoTotalsDiffIntoRecords(baseline, current)
+
+	// Assert
+	assert.Equal(t, [][]string{
+		{"organization", "repository", "baselineLineOfCodeCount", "lineOfCodeCount", "delta"},
+		{"org", "org-b", "50", "0", "-50"},
+		{"org", "org-c", "0", "30", "30"},
+		{"org", "org-a", "100", "90", "-10"},
+		{"", "total", "150", "120", "-30"},
+	}, records)
+}
+
+func Test_report_ConvertRepoTotalsDiffIntoRecords_old_schema_baseline(t *testing.T) {
+	// baselines written before the organization column only have the repository and its LOC
+	baselinePath := filepath.Join(t.TempDir(), COMBINEDREPORTFILENAME)
+	os.WriteFile(baselinePath, []byte("repository,lineOfCodeCount\norg-a,100\norg-b,50\ntotal,150\n"), 0644)
+	baseline, err := ReadRepoTotals(baselinePath)
+	assert.NoError(t, err)
+	current := []RepoTotal{
+		{RepositoryId: "org-a", OrganizationName: "org", CodeLineCount: 90},
+		{RepositoryId: "org-b", OrganizationName: "org", CodeLineCount: 50},
+	}
+
+	records := ConvertRepoTotalsDiffIntoRecords(baseline, current)
+
+	// Assert
+	assert.Equal(t, [][]string{
+		{"organization", "repository", "baselineLineOfCodeCount", "lineOfCodeCount", "delta"},
+		{"org", "org-a", "100", "90", "-10"},
+		{"org", "org-b", "50", "50", "0"},
+		{"", "total", "150", "140", "-10"},
+	}, records)
+}
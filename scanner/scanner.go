@@ -17,6 +17,10 @@ type FileScanResults struct {
 	CodeLineCount     int
 	BlankLineCount    int
 	CommentsLineCount int
+	// Language is the name of the language in Languages, empty if the file is not supported
+	Language string
+	// Bytes is the size of the file, used to calibrate LOC estimates
+	Bytes int64
 }
 type AnalyzeLineResult string
 
@@ -69,7 +73,7 @@ func ScanFile(filePath string) FileScanResults {
 	// Get metadata about file
 	fileName := f.Name()
 	suffix := ParseFileSuffix(fileName)
-	_, languageInfo, found := LookupByExtension(suffix)
+	language, languageInfo, found := LookupByExtension(suffix)
 	// If not supported return 0s, TODO should probably throw an error or report on it
 	// TODO Dockerfile does not always have an extension, but we will count it
 	if !found {
@@ -120,6 +124,10 @@ func ScanFile(filePath string) FileScanResults {
 	result.BlankLineCount = blankLineCount
 	result.CommentsLineCount = commentsLineCount
 	result.FilePath = filePath
+	result.Language = language
+	if fileInfo, err := f.Stat(); err == nil {
+		result.Bytes = fileInfo.Size()
+	}
 	return result
 
 }
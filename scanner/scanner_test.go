@@ -14,6 +14,8 @@ func Test_scanner_ScanFile_javascript_easy(t *testing.T) {
 	assert.Equal(t, 16, result.CodeLineCount)
 	assert.Equal(t, 9, result.CommentsLineCount)
 	assert.Equal(t, 7, result.BlankLineCount)
+	assert.Equal(t, "JavaScript", result.Language)
+	assert.Equal(t, int64(690), result.Bytes)
 }
 func Test_scanner_ScanFile_javascript_hard(t *testing.T) {
 	result := ScanFile("test-files/js/hard.js")
@@ -0,0 +1,196 @@
+package utilities
+
+import (
+	"flag"
+	"fmt"
+	"go-cloc/logger"
+	"go-cloc/report"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Commands
+const (
+	SCAN      string = "scan"
+	DISCOVER  string = "discover"
+	REPORT    string = "report"
+	DIFF      string = "diff"
+	LANGUAGES string = "languages"
+	VERSION   string = "version"
+	HELP      string = "help"
+)
+
+// Version is set when building, e.g. go build -ldflags "-X go-cloc/utilities.Version=1.2.0"
+var Version = "dev"
+
+// commands lists every command in the order they are printed by help
+var commands = []string{SCAN, DISCOVER, REPORT, DIFF, LANGUAGES, VERSION}
+
+// commandUsages holds the synopsis and the description of every command
+var commandUsages = map[string][2]string{
+	SCAN:      {"scan [flags] [path]", "Discover, clone and scan repositories, or scan a local file or directory"},
+	DISCOVER:  {"discover [flags]", "Discover and filter repositories, writing an inventory without cloning anything"},
+	REPORT:    {"report [flags] <results-directory>", "Render the combined report of existing results again"},
+	DIFF:      {"diff [flags] <baseline-results> <results>", "Compare the LOC of every repository between two results"},
+	LANGUAGES: {"languages", "List the supported languages and their file extensions"},
+	VERSION:   {"version", "Print the version of go-cloc"},
+}
+
+// ReportArgs are the arguments of the report command
+type ReportArgs struct {
+	LogLevel             string
+	ResultsDirectoryPath string
+	OutputFilePath       string
+	// Format is csv, html or markdown
+	Format string
+	// BaselinePath and TopFiles are the options of the markdown format
+	BaselinePath string
+	TopFiles     int
+}
+
+// DiffArgs are the arguments of the diff command
+type DiffArgs struct {
+	LogLevel       string
+	BaselinePath   string
+	CurrentPath    string
+	OutputFilePath string
+}
+
+// ParseCommand splits the command from its arguments.
+// Without a command, the arguments are the flags of scan, as they were before commands existed
+func ParseCommand(arguments []string) (string, []string) {
+	if len(arguments) == 0 {
+		return HELP, nil
+	}
+	if _, ok := commandUsages[arguments[0]]; ok || arguments[0] == HELP {
+		return arguments[0], arguments[1:]
+	}
+	return SCAN, arguments
+}
+
+// PrintUsage prints the list of commands
+func PrintUsage(output io.Writer) {
+	fmt.Fprintln(output, "Usage: go-cloc <command> [flags]")
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "Commands:")
+	for _, command := range commands {
+		fmt.Fprintf(output, "  %-10s %s\n", command, commandUsages[command][1])
+	}
+	fmt.Fprintln(output)
+	fmt.Fprintln(output, "Run 'go-cloc <command> --help' for the flags of a command. Flags without a command are passed to scan")
+}
+
+// newCommandFlagSet creates the flags of a command, printing its synopsis as help
+func newCommandFlagSet(command string) *flag.FlagSet {
+	flagSet := flag.NewFlagSet(command, flag.ExitOnError)
+	flagSet.Usage = func() {
+		usage := commandUsages[command]
+		fmt.Fprintln(flagSet.Output(), "Usage: go-cloc "+usage[0]+"\n\n"+usage[1])
+		hasFlags := false
+		flagSet.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(flagSet.Output(), "\nFlags:")
+			flagSet.PrintDefaults()
+		}
+	}
+	return flagSet
+}
+
+// parseInterspersed parses the flags of a command, which can be set before or after its arguments, and returns the arguments
+func parseInterspersed(flagSet *flag.FlagSet, arguments []string) []string {
+	positionalArgs := []string{}
+	for {
+		flagSet.Parse(arguments)
+		arguments = flagSet.Args()
+		if len(arguments) == 0 {
+			return positionalArgs
+		}
+		positionalArgs = append(positionalArgs, arguments[0])
+		arguments = arguments[1:]
+	}
+}
+
+// parsePositionalArgs parses the flags of a command, exiting unless exactly the expected number of arguments are given
+func parsePositionalArgs(flagSet *flag.FlagSet, arguments []string, expected int) []string {
+	positionalArgs := parseInterspersed(flagSet, arguments)
+	if len(positionalArgs) != expected {
+		fmt.Fprintln(flagSet.Output(), "Expected", expected, "arguments, got", len(positionalArgs))
+		flagSet.Usage()
+		os.Exit(2)
+	}
+	return positionalArgs
+}
+
+// setupCommandLogger logs to standard error, so the output of a command can be piped
+func setupCommandLogger(logLevel string) {
+	logger.SetLogLevel(logger.ConvertStringToLogLevel(logLevel))
+	logger.SetOutput(os.Stderr)
+}
+
+// ParseCommandWithoutFlags handles the help of commands that take no flags, such as languages and version
+func ParseCommandWithoutFlags(command string, arguments []string) {
+	parsePositionalArgs(newCommandFlagSet(command), arguments, 0)
+}
+
+// ParseReportArgs parses the arguments of the report command
+func ParseReportArgs(arguments []string) ReportArgs {
+	flagSet := newCommandFlagSet(REPORT)
+	logLevelArg := flagSet.String("log-level", "INFO", "Log level (DEBUG, INFO, WARN, ERROR)")
+	outputFileArg := flagSet.String("output-file", "", "(Optional) Path of the file to write the report to. By default the report is printed to standard output")
+	formatArg := flagSet.String("format", report.CSV, "(Optional) Format of the report: <csv>||<json>||<cloc-json>||<cloc-yaml>||<cloc-xml>||<cloc-csv>||<html>||<markdown>, see scan --help. The results directory does not record the mode, organization and times of the scan, so they are left at their zero value")
+	baselineArg := flagSet.String("baseline", "", "(Optional) For --format markdown, path to the results of a previous scan, to add a table of the changes in LOC per repository")
+	topFilesArg := flagSet.Int("top-files", report.DEFAULTTOPFILES, "(Optional) For --format markdown, number of largest files to list, 0 leaves them out")
+	positionalArgs := parsePositionalArgs(flagSet, arguments, 1)
+
+	args := ReportArgs{
+		LogLevel:             *logLevelArg,
+		ResultsDirectoryPath: positionalArgs[0],
+		OutputFilePath:       *outputFileArg,
+		Format:               strings.ToLower(*formatArg),
+		BaselinePath:         *baselineArg,
+		TopFiles:             *topFilesArg,
+	}
+	setupCommandLogger(args.LogLevel)
+	if !slices.Contains(report.Formats, args.Format) {
+		logger.Error("Invalid --format ", args.Format, ", expected one of ", strings.Join(report.Formats, ", "))
+		os.Exit(-1)
+	}
+	validateMarkdownOptions(args.Format, args.BaselinePath, args.TopFiles)
+	return args
+}
+
+// validateMarkdownOptions exits if the options of the markdown format are set for another format
+func validateMarkdownOptions(format string, baselinePath string, topFiles int) {
+	if format != report.MARKDOWN && baselinePath != "" {
+		logger.Error("--baseline requires : --format markdown")
+		os.Exit(-1)
+	}
+	// checked before scanning, rather than once the scan is done
+	if _, err := os.Stat(baselinePath); baselinePath != "" && err != nil {
+		logger.Error("Invalid --baseline: ", err)
+		os.Exit(-1)
+	}
+	if topFiles < 0 {
+		logger.Error("Invalid --top-files ", topFiles, ", expected a positive number")
+		os.Exit(-1)
+	}
+}
+
+// ParseDiffArgs parses the arguments of the diff command
+func ParseDiffArgs(arguments []string) DiffArgs {
+	flagSet := newCommandFlagSet(DIFF)
+	logLevelArg := flagSet.String("log-level", "INFO", "Log level (DEBUG, INFO, WARN, ERROR)")
+	outputFileArg := flagSet.String("output-file", "", "(Optional) Path of the file to write the comparison to. By default it is printed to standard output")
+	positionalArgs := parsePositionalArgs(flagSet, arguments, 2)
+
+	args := DiffArgs{
+		LogLevel:       *logLevelArg,
+		BaselinePath:   positionalArgs[0],
+		CurrentPath:    positionalArgs[1],
+		OutputFilePath: *outputFileArg,
+	}
+	setupCommandLogger(args.LogLevel)
+	return args
+}
@@ -0,0 +1,29 @@
+package utilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_utilities_ParseCommand(t *testing.T) {
+	command, arguments := ParseCommand([]string{"report", "results", "--output-file", "report.csv"})
+	// Assert
+	assert.Equal(t, REPORT, command)
+	assert.Equal(t, []string{"results", "--output-file", "report.csv"}, arguments)
+
+	// flags without a command are passed to scan
+	command, arguments = ParseCommand([]string{"--devops", "GitHub"})
+	assert.Equal(t, SCAN, command)
+	assert.Equal(t, []string{"--devops", "GitHub"}, arguments)
+
+	command, _ = ParseCommand([]string{})
+	assert.Equal(t, HELP, command)
+}
+
+func Test_utilities_ParseReportArgs_flags_after_arguments(t *testing.T) {
+	args := ParseReportArgs([]string{"results", "--output-file", "report.csv"})
+	// Assert
+	assert.Equal(t, "results", args.ResultsDirectoryPath)
+	assert.Equal(t, "report.csv", args.OutputFilePath)
+}
@@ -0,0 +1,135 @@
+package utilities
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go-cloc/logger"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// TOKENENV is the environment variable holding the access token for any provider
+const TOKENENV string = "GO_CLOC_TOKEN"
+
+// ProviderTokenEnv returns the provider specific environment variable of the access token, e.g. GO_CLOC_GITHUB_TOKEN
+func ProviderTokenEnv(mode string) string {
+	return "GO_CLOC_" + strings.ToUpper(mode) + "_TOKEN"
+}
+
+// ProviderHost returns the host the git credential helpers are asked for
+func ProviderHost(mode string, baseURL string) string {
+	if baseURL != "" {
+		u, err := url.Parse(baseURL)
+		if err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	switch mode {
+	case GITHUB:
+		return "github.com"
+	case AZUREDEVOPS:
+		return "dev.azure.com"
+	case GITLAB:
+		return "gitlab.com"
+	case BITBUCKET:
+		return "bitbucket.org"
+	}
+	return ""
+}
+
+// readTokenFile reads the token from a file, ignoring surrounding whitespace such as a trailing newline
+func readTokenFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+// readGitCredential asks the configured git credential helpers for the password of the host, without prompting
+func readGitCredential(host string) (string, error) {
+	cmd := exec.Command("git", "credential", "fill")
+	cmd.Stdin = strings.NewReader("protocol=https\nhost=" + host + "\n\n")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	output := bufio.NewScanner(&stdout)
+	for output.Scan() {
+		if password, found := strings.CutPrefix(output.Text(), "password="); found {
+			return password, nil
+		}
+	}
+	return "", nil
+}
+
+/*
+ResolveAccessToken resolves the access token in order of precedence:
+ 1. --accessToken
+ 2. --access-token-file
+ 3. the environment variable named by --access-token-env
+ 4. the provider specific environment variable, e.g. GO_CLOC_GITHUB_TOKEN
+ 5. GO_CLOC_TOKEN
+ 6. git credential fill for the host of the provider
+
+The resolved token is registered as a secret so it is masked in logs and reports
+*/
+func ResolveAccessToken(mode string, baseURL string, accessToken string, accessTokenFilePath string, accessTokenEnv string) (string, error) {
+	tried := []string{}
+	token, source := "", ""
+
+	if accessToken != "" {
+		token, source = accessToken, "--accessToken"
+		logger.Warn("--accessToken is visible in your shell history and process list, consider using --access-token-file or ", TOKENENV, " instead")
+	}
+	tried = append(tried, "--accessToken")
+
+	if token == "" && accessTokenFilePath != "" {
+		fileToken, err := readTokenFile(accessTokenFilePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read --access-token-file %s: %w", accessTokenFilePath, err)
+		}
+		token, source = fileToken, "--access-token-file"
+	}
+	tried = append(tried, "--access-token-file")
+
+	if token == "" && accessTokenEnv != "" {
+		// an explicitly named variable must be set
+		token, source = os.Getenv(accessTokenEnv), accessTokenEnv
+		if token == "" {
+			return "", fmt.Errorf("environment variable %s of --access-token-env is not set", accessTokenEnv)
+		}
+	}
+
+	for _, env := range []string{ProviderTokenEnv(mode), TOKENENV} {
+		if token == "" {
+			token, source = os.Getenv(env), env
+		}
+		tried = append(tried, env)
+	}
+
+	host := ProviderHost(mode, baseURL)
+	if token == "" && host != "" {
+		gitToken, err := readGitCredential(host)
+		if err != nil {
+			logger.Debug("git credential fill for ", host, " failed: ", err)
+		}
+		token, source = gitToken, "git credential fill"
+	}
+	if host != "" {
+		tried = append(tried, "git credential fill for "+host)
+	}
+
+	if token == "" {
+		return "", fmt.Errorf("mode %s requires an access token, none was found in: %s", mode, strings.Join(tried, ", "))
+	}
+	logger.RegisterSecret(token)
+	logger.Debug("Using the access token from ", source)
+	return token, nil
+}
@@ -0,0 +1,77 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// isolateGitConfig prevents the git credential helpers of the machine running the tests from being used
+func isolateGitConfig(t *testing.T, gitConfig string) {
+	gitConfigPath := filepath.Join(t.TempDir(), "gitconfig")
+	os.WriteFile(gitConfigPath, []byte(gitConfig), 0644)
+	t.Setenv("GIT_CONFIG_GLOBAL", gitConfigPath)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv(TOKENENV, "")
+	t.Setenv(ProviderTokenEnv(GITHUB), "")
+}
+
+func Test_utilities_ResolveAccessToken_precedence(t *testing.T) {
+	isolateGitConfig(t, "")
+	tokenFilePath := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenFilePath, []byte("file-token\n"), 0600)
+	t.Setenv(TOKENENV, "env-token")
+
+	token, err := ResolveAccessToken(GITHUB, "", "flag-token", tokenFilePath, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "flag-token", token)
+
+	token, err = ResolveAccessToken(GITHUB, "", "", tokenFilePath, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "file-token", token)
+
+	token, err = ResolveAccessToken(GITHUB, "", "", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "env-token", token)
+
+	t.Setenv("GO_CLOC_GITHUB_TOKEN", "github-token")
+	token, err = ResolveAccessToken(GITHUB, "", "", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "github-token", token)
+
+	t.Setenv("WEEKLY_GITHUB_TOKEN", "named-env-token")
+	token, err = ResolveAccessToken(GITHUB, "", "", "", "WEEKLY_GITHUB_TOKEN")
+	assert.NoError(t, err)
+	assert.Equal(t, "named-env-token", token)
+	_, err = ResolveAccessToken(GITHUB, "", "", "", "UNSET_GITHUB_TOKEN")
+	assert.EqualError(t, err, "environment variable UNSET_GITHUB_TOKEN of --access-token-env is not set")
+}
+
+func Test_utilities_ResolveAccessToken_git_credential(t *testing.T) {
+	isolateGitConfig(t, "[credential]\n\thelper = \"!f() { echo username=x; echo password=helper-token; }; f\"\n")
+
+	token, err := ResolveAccessToken(GITEA, "https://git.example.com", "", "", "")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "helper-token", token)
+}
+
+func Test_utilities_ResolveAccessToken_not_found(t *testing.T) {
+	isolateGitConfig(t, "")
+	t.Setenv(ProviderTokenEnv(GITLAB), "")
+
+	_, err := ResolveAccessToken(GITLAB, "", "", "", "")
+
+	// Assert
+	assert.EqualError(t, err, "mode GitLab requires an access token, none was found in: --accessToken, --access-token-file, GO_CLOC_GITLAB_TOKEN, GO_CLOC_TOKEN, git credential fill for gitlab.com")
+}
+
+func Test_utilities_ProviderHost(t *testing.T) {
+	// Assert
+	assert.Equal(t, "github.com", ProviderHost(GITHUB, ""))
+	assert.Equal(t, "dev.azure.com", ProviderHost(AZUREDEVOPS, ""))
+	assert.Equal(t, "git.example.com:3000", ProviderHost(GITEA, "https://git.example.com:3000/"))
+}
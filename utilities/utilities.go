@@ -2,9 +2,19 @@ package utilities
 
 import (
 	"flag"
+	"go-cloc/clone"
+	"go-cloc/config"
+	"go-cloc/devops"
+	"go-cloc/estimate"
+	"go-cloc/github"
+	"go-cloc/httpclient"
+	"go-cloc/inventory"
 	"go-cloc/logger"
+	"go-cloc/report"
 	"go-cloc/scanner"
 	"os"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -15,66 +25,290 @@ const (
 	AZUREDEVOPS string = "AzureDevOps"
 	GITLAB      string = "GitLab"
 	BITBUCKET   string = "Bitbucket"
+	GITEA       string = "Gitea"
+	GIT         string = "Git"
+	LOCALREPOS  string = "LocalRepositories"
 )
 
 type CLIArgs struct {
-	LogLevel             string
-	Mode                 string
-	LocalScanFilePath    string
-	AccessToken          string
-	Organization         string
-	IgnorePatterns       []string
-	ExcludeRepositories  []string
-	IncludeRepositories  []string
-	CloneRepoUsingZip    bool
-	DumpCSVs             bool
-	ResultsDirectoryPath string
+	LogLevel                  string
+	Mode                      string
+	LocalScanFilePath         string
+	LocalGitReposOnly         bool
+	AccessToken               string
+	Organization              string
+	Organizations             []string
+	BaseURL                   string
+	GithubAppId               string
+	GithubAppInstallationId   string
+	GithubAppPrivateKeyPath   string
+	GithubDiscovery           string
+	RepositoriesFilePath      string
+	IgnorePatterns            []string
+	ExcludeRepositories       []devops.RepoRule
+	IncludeRepositories       []devops.RepoRule
+	ListRepos                 bool
+	DryRun                    bool
+	InventoryFormat           string
+	InventoryFilePath         string
+	RepositoriesInventoryPath string
+	RepoFilter                devops.RepoFilter
+	IncludeProjects           []string
+	ExcludeProjects           []string
+	Ref                       string
+	Branches                  string
+	CloneRepoUsingZip         bool
+	CloneProtocol             string
+	SSHOptions                clone.SSHOptions
+	Estimate                  bool
+	EstimateFactors           estimate.Factors
+	DumpCSVs                  bool
+	GroupByProject            bool
+	ResultsDirectoryPath      string
+	// Format of the combined report, see report.Formats
+	Format string
+	// OutputFilePath is where the combined report is written for formats other than csv, empty for standard output
+	OutputFilePath string
+	// BaselinePath and TopFiles are the options of the markdown format
+	BaselinePath string
+	TopFiles     int
+	// ConfigFilePath is set when the sources are described by a configuration file, see ParseConfigSources
+	ConfigFilePath string
+	// ConfigArguments are the flags shared by every source of the configuration file
+	ConfigArguments []string
+	// SourceName is the name of the source of the configuration file
+	SourceName string
 }
 
-func ParseArgsFromCLI() CLIArgs {
+// configGlobalFlags can be combined with --config, every other flag is set per source in the configuration file
+var configGlobalFlags = []string{"config", "log-level", "proxy", "ca-bundle", "client-cert", "client-key", "insecure-skip-verify", "requests-per-second", "results-directory-path", "dump-csvs", "group-by-project", "format", "output-file", "baseline", "top-files"}
+
+// SplitCommaSeparatedList splits a value such as "a, b,c" into its trimmed, non-empty items
+func SplitCommaSeparatedList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		trimmed := strings.TrimSpace(item)
+		if trimmed != "" {
+			items = append(items, trimmed)
+		}
+	}
+	return items
+}
+
+// readRepoRulesFile reads an include or exclude repositories file, exiting if a rule is invalid
+func readRepoRulesFile(path string) []devops.RepoRule {
+	rules, err := devops.ParseRepoRules(scanner.ReadIgnoreFile(path))
+	if err != nil {
+		logger.Error("Failed to parse ", path, ": ", err)
+		os.Exit(-1)
+	}
+	return rules
+}
+
+// ParseScanArgs parses the arguments of the scan and discover commands.
+// Flags only used when scanning are not available to discover, which keeps their defaults
+func ParseScanArgs(command string, arguments []string) CLIArgs {
+	flagSet := newCommandFlagSet(command)
+	scanFlagSet := flagSet
+	if command == DISCOVER {
+		// never parsed
+		scanFlagSet = flag.NewFlagSet(command, flag.ExitOnError)
+	}
+
+	configFileArg := scanFlagSet.String("config", "", "(Optional) Path to a YAML or TOML file describing several sources to scan in one run with a combined report. Please see the README.md for the format")
 
 	// mandatory arguments
-	modeArg := flag.String("devops", LOCAL, "flag : <GitHub>||<AzureDevOps>||<Bitbucket>||<GitLab>||<File>")
-	accessTokenArg := flag.String("accessToken", "", "Your DevOps personal access token used for discovering and downloading repositories in your organization")
-	organizationArg := flag.String("organization", "", "Your DevOps organization name")
+	modeArg := flagSet.String("devops", LOCAL, "flag : <GitHub>||<AzureDevOps>||<Bitbucket>||<GitLab>||<Gitea>||<Git>||<LocalRepositories>||<File>")
+	accessTokenArg := flagSet.String("accessToken", "", "Your DevOps personal access token used for discovering and downloading repositories in your organization. Prefer --access-token-file or the GO_CLOC_TOKEN environment variable, see the README.md")
+	accessTokenFileArg := flagSet.String("access-token-file", "", "(Optional) Path to a file containing your DevOps personal access token")
+	accessTokenEnvArg := flagSet.String("access-token-env", "", "(Optional) Name of an environment variable containing your DevOps personal access token")
+	organizationArg := flagSet.String("organization", "", "Your DevOps organization name. Multiple organizations can be provided as a comma separated list. For GitHub, a user account name can be used as well")
 	// optional arguments
-	logLevelArg := flag.String("log-level", "INFO", "Log level (DEBUG, INFO, WARN, ERROR)")
-	localScanFilePathArg := flag.String("local-file-path", "", "Path to your local file or directory that you want to scan")
-	ignoreFilePathArg := flag.String("ignore-file", "", "(Optional) Path to your ignore file to exclude directories and files. Please see the README.md for how to format your ignore configuration")
-	excludeRepositoriesFilePathArg := flag.String("exclude-repositories-file", "", "(Optional) Path to your exclude repositories file to exclude repositories. Please see the README.md for how to format your exclude repositories configuration")
-	includeRepositoriesFilePathArg := flag.String("include-repositories-file", "", "(Optional) Path to your include repositories file to include repositories. Please see the README.md for how to format your include repositories configuration")
-	cloneRepoUsingZipArg := flag.Bool("clone-repo-using-zip", false, "(Optional) Flag to clone repositories using zip files instead of git clone for faster downloads. Default is false. For Github, a fine-grained token is required for private repositories")
-	dumpCSVsArg := flag.Bool("dump-csvs", true, "(Optional) Flag to output CSV files. Default is true, but can be set to false to disable file dumps")
-	resultsDirectoryPathArg := flag.String("results-directory-path", "", "(Optional) Path to a new directory for storing the results. By default the tool will create one")
+	baseURLArg := flagSet.String("base-url", "", "(Optional) Base URL of a self-hosted DevOps instance, for example https://git.example.com. Required for Gitea / Forgejo")
+	githubAppIdArg := flagSet.String("github-app-id", "", "(Optional) For GitHub, authenticate as the GitHub App with this id instead of using --accessToken. Requires --github-app-private-key-file")
+	githubAppInstallationIdArg := flagSet.String("github-app-installation-id", "", "(Optional) For GitHub, id of the GitHub App installation. By default the installation is looked up using --organization")
+	githubAppPrivateKeyFileArg := flagSet.String("github-app-private-key-file", "", "(Optional) For GitHub, path to the PEM private key of the GitHub App")
+	githubDiscoveryArg := flagSet.String("github-discovery", github.GRAPHQL, "(Optional) For GitHub, API used to discover repositories: <graphql>||<rest>. GraphQL falls back to REST if it fails")
+	repositoriesFilePathArg := flagSet.String("repositories-file", "", "(Optional) Path to a file listing git URLs to clone and scan, one per line as '<url> [ref] [name]'. Required for Git")
+	logLevelArg := flagSet.String("log-level", "INFO", "Log level (DEBUG, INFO, WARN, ERROR)")
+	localScanFilePathArg := flagSet.String("local-file-path", "", "Path to your local file or directory that you want to scan")
+	localGitReposOnlyArg := flagSet.Bool("local-git-repos-only", false, "(Optional) For LocalRepositories, only treat directories containing .git as repositories instead of every immediate child directory. Default is false")
+	ignoreFilePathArg := scanFlagSet.String("ignore-file", "", "(Optional) Path to your ignore file to exclude directories and files. Please see the README.md for how to format your ignore configuration")
+	excludeRepositoriesFilePathArg := flagSet.String("exclude-repositories-file", "", "(Optional) Path to your exclude repositories file to exclude repositories. Please see the README.md for how to format your exclude repositories configuration")
+	includeRepositoriesFilePathArg := flagSet.String("include-repositories-file", "", "(Optional) Path to your include repositories file to include repositories. Please see the README.md for how to format your include repositories configuration")
+	includeProjectsArg := flagSet.String("include-projects", "", "(Optional) Comma separated list of Azure DevOps projects to discover repositories in. By default all projects are discovered")
+	excludeProjectsArg := flagSet.String("exclude-projects", "", "(Optional) Comma separated list of Azure DevOps projects to skip during discovery")
+	skipArchivedArg := flagSet.Bool("skip-archived", false, "(Optional) Flag to skip archived repositories. Default is false")
+	skipForksArg := flagSet.Bool("skip-forks", false, "(Optional) Flag to skip forked repositories. Default is false")
+	skipMirrorsArg := flagSet.Bool("skip-mirrors", false, "(Optional) Flag to skip mirrored repositories. Default is false")
+	skipTemplatesArg := flagSet.Bool("skip-templates", false, "(Optional) Flag to skip template repositories. Default is false")
+	skipEmptyArg := flagSet.Bool("skip-empty", false, "(Optional) Flag to skip empty repositories. Default is false")
+	visibilityArg := flagSet.String("visibility", "", "(Optional) Comma separated list of repository visibilities to scan, e.g. private,internal. By default all visibilities are scanned")
+	pushedSinceArg := flagSet.String("pushed-since", "", "(Optional) Only scan repositories pushed to on or after this date, formatted as YYYY-MM-DD")
+	listReposArg := flagSet.Bool("list-repos", false, "(Optional) Flag to only discover and filter repositories, listing which rule included or excluded each repository without scanning. Default is false")
+	dryRunArg := scanFlagSet.Bool("dry-run", false, "(Optional) Flag to only discover and filter repositories, writing an inventory of the repositories that would be scanned. Default is false")
+	inventoryFormatArg := flagSet.String("inventory-format", "csv", "(Optional) Format of the inventory written by --dry-run: <csv>||<json>. When --inventory-file is set, it must match its extension")
+	inventoryFileArg := flagSet.String("inventory-file", "", "(Optional) Path of the inventory written by --dry-run. By default it is written to the results directory. The format follows the extension, .json for JSON and CSV otherwise")
+	repositoriesInventoryArg := flagSet.String("repositories-inventory", "", "(Optional) Path to an inventory written by --dry-run, possibly edited, to scan instead of discovering repositories")
+	refArg := scanFlagSet.String("ref", "", "(Optional) Branch or tag to scan in every repository instead of the default branch")
+	branchesArg := scanFlagSet.String("branches", "", "(Optional) Glob of branches to scan in every repository, e.g. release/*. The branch with the most LOC is selected per repository")
+	largestBranchArg := scanFlagSet.Bool("largest-branch", false, "(Optional) Flag to scan every branch and select the branch with the most LOC per repository. Same as --branches '*'. Default is false")
+	proxyArg := flagSet.String("proxy", "", "(Optional) URL of the proxy used for all network calls, e.g. http://proxy.example.com:8080. By default HTTPS_PROXY, HTTP_PROXY and NO_PROXY are used")
+	caBundleArg := flagSet.String("ca-bundle", "", "(Optional) Path to a PEM file of certificate authorities to trust in addition to the system ones")
+	clientCertArg := flagSet.String("client-cert", "", "(Optional) Path to a PEM client certificate for mutual TLS. Requires --client-key")
+	clientKeyArg := flagSet.String("client-key", "", "(Optional) Path to the PEM private key of --client-cert")
+	insecureSkipVerifyArg := flagSet.Bool("insecure-skip-verify", false, "(Optional) Flag to disable TLS certificate verification, only meant for lab environments. Default is false")
+	requestsPerSecondArg := flagSet.Float64("requests-per-second", 0, "(Optional) Maximum number of API requests per second. By default a limit suited to the DevOps platform is used")
+	cloneRepoUsingZipArg := scanFlagSet.Bool("clone-repo-using-zip", false, "(Optional) Flag to clone repositories using zip files instead of git clone for faster downloads. Default is false. For Github, a fine-grained token is required for private repositories")
+	cloneProtocolArg := scanFlagSet.String("clone-protocol", clone.HTTPS, "(Optional) Protocol used to clone repositories: <https>||<ssh>. SSH uses the ssh-agent unless --ssh-key-file is set")
+	sshKeyFileArg := scanFlagSet.String("ssh-key-file", "", "(Optional) Path to the private key used to clone repositories over SSH. By default the ssh-agent is used")
+	sshKeyPassphraseArg := scanFlagSet.String("ssh-key-passphrase", "", "(Optional) Passphrase of the private key set with --ssh-key-file")
+	sshKeyPassphraseEnvArg := scanFlagSet.String("ssh-key-passphrase-env", "", "(Optional) Name of an environment variable containing the passphrase of the private key set with --ssh-key-file")
+	sshKnownHostsFileArg := scanFlagSet.String("ssh-known-hosts-file", "", "(Optional) Path to the known_hosts file used to verify SSH hosts. By default SSH_KNOWN_HOSTS or ~/.ssh/known_hosts is used")
+	sshStrictHostKeyCheckingArg := scanFlagSet.Bool("ssh-strict-host-key-checking", true, "(Optional) Flag to reject SSH hosts missing from the known_hosts file. Default is true, set to false to accept any host key")
+	estimateArg := scanFlagSet.Bool("estimate", false, "(Optional) Flag to estimate LOC from the language statistics of the DevOps platform instead of cloning and scanning. Supported for GitHub, GitLab, Bitbucket and Gitea. Default is false")
+	calibrationFileArg := scanFlagSet.String("calibration-file", "", "(Optional) For --estimate, path to an AAA-bytes-per-line.csv written by a previous scan, to convert bytes into LOC using the factors measured on your own code")
+	dumpCSVsArg := flagSet.Bool("dump-csvs", true, "(Optional) Flag to output CSV files. Default is true, but can be set to false to disable file dumps")
+	groupByProjectArg := scanFlagSet.Bool("group-by-project", false, "(Optional) Flag to also output LOC totals rolled up per project, e.g. Azure DevOps projects, Bitbucket projects or GitLab subgroups. Default is false")
+	resultsDirectoryPathArg := flagSet.String("results-directory-path", "", "(Optional) Path to a new directory for storing the results. By default the tool will create one")
+	formatArg := scanFlagSet.String("format", report.CSV, "(Optional) Format of the combined report: <csv>||<json>||<cloc-json>||<cloc-yaml>||<cloc-xml>||<cloc-csv>||<html>||<markdown>. JSON is a single document with the results by repository, language and file. The cloc formats mirror the output of cloc --json, --yaml, --xml and --csv --by-file. HTML is a single file with charts and tables drilling down to every file. Markdown is a summary for pull request comments and CI job summaries")
+	outputFileArg := scanFlagSet.String("output-file", "", "(Optional) For --format other than csv, path of the file to write the report to. By default the report is printed to standard output")
+	baselineArg := scanFlagSet.String("baseline", "", "(Optional) For --format markdown, path to the results of a previous scan, to add a table of the changes in LOC per repository")
+	topFilesArg := scanFlagSet.Int("top-files", report.DEFAULTTOPFILES, "(Optional) For --format markdown, number of largest files to list, 0 leaves them out")
 
 	// parse the CLI arguments
-	flag.Parse()
+	positionalArgs := parseInterspersed(flagSet, arguments)
 
 	// dereference all CLI args to make it easier to use
 	logLevel := *logLevelArg
+	configFilePath := *configFileArg
 	mode := *modeArg
 	localScanFilePath := *localScanFilePathArg
+	localGitReposOnly := *localGitReposOnlyArg
 	accessToken := *accessTokenArg
+	accessTokenFilePath := *accessTokenFileArg
+	accessTokenEnv := *accessTokenEnvArg
 	organization := *organizationArg
+	baseURL := *baseURLArg
+	githubAppId := *githubAppIdArg
+	githubAppInstallationId := *githubAppInstallationIdArg
+	githubAppPrivateKeyPath := *githubAppPrivateKeyFileArg
+	githubDiscovery := strings.ToLower(*githubDiscoveryArg)
+	repositoriesFilePath := *repositoriesFilePathArg
 	ignoreFilePath := *ignoreFilePathArg
 	excludeRepositoriesFilePath := *excludeRepositoriesFilePathArg
 	includeRepositoriesFilePath := *includeRepositoriesFilePathArg
+	includeProjects := SplitCommaSeparatedList(*includeProjectsArg)
+	excludeProjects := SplitCommaSeparatedList(*excludeProjectsArg)
+	repoFilter := devops.RepoFilter{
+		SkipArchived:  *skipArchivedArg,
+		SkipForks:     *skipForksArg,
+		SkipMirrors:   *skipMirrorsArg,
+		SkipTemplates: *skipTemplatesArg,
+		SkipEmpty:     *skipEmptyArg,
+		Visibilities:  SplitCommaSeparatedList(*visibilityArg),
+	}
+	pushedSince := *pushedSinceArg
+	listRepos := *listReposArg
+	dryRun := *dryRunArg
+	inventoryFormat := strings.ToLower(*inventoryFormatArg)
+	inventoryFilePath := *inventoryFileArg
+	repositoriesInventoryPath := *repositoriesInventoryArg
+	ref := *refArg
+	branches := *branchesArg
+	largestBranch := *largestBranchArg
+	transportOptions := httpclient.TransportOptions{
+		ProxyURL:           *proxyArg,
+		CABundlePath:       *caBundleArg,
+		ClientCertPath:     *clientCertArg,
+		ClientKeyPath:      *clientKeyArg,
+		InsecureSkipVerify: *insecureSkipVerifyArg,
+	}
+	requestsPerSecond := *requestsPerSecondArg
 	cloneRepoUsingZip := *cloneRepoUsingZipArg
+	cloneProtocol := strings.ToLower(*cloneProtocolArg)
+	sshOptions := clone.SSHOptions{
+		KeyFilePath:           *sshKeyFileArg,
+		KeyPassphrase:         *sshKeyPassphraseArg,
+		KnownHostsFilePath:    *sshKnownHostsFileArg,
+		StrictHostKeyChecking: *sshStrictHostKeyCheckingArg,
+	}
+	sshKeyPassphraseEnv := *sshKeyPassphraseEnvArg
+	estimateLOC := *estimateArg
+	calibrationFilePath := *calibrationFileArg
 	dumpCSVs := *dumpCSVsArg
+	groupByProject := *groupByProjectArg
 	resultsDirectoryPath := *resultsDirectoryPathArg
+	format := strings.ToLower(*formatArg)
+	outputFilePath := *outputFileArg
+	baselinePath := *baselineArg
+	topFiles := *topFilesArg
+
+	// mask the credentials in every log line and report. The SSH key passphrase is never logged,
+	// and a short passphrase would mask unrelated text such as file paths
+	logger.RegisterSecret(accessToken)
 
 	// set log level
 	logger.SetLogLevel(logger.ConvertStringToLogLevel(logLevel))
-	logger.SetOutput(os.Stdout)
+	if format != report.CSV && outputFilePath == "" {
+		// the report is printed to standard output, keep the logs out of it
+		logger.SetOutput(os.Stderr)
+	} else {
+		logger.SetOutput(os.Stdout)
+	}
 
 	logger.Info("Setting Log Level to " + logLevel)
 	logger.Info("Parsing CLI arguments")
 
+	// the sources of a configuration file are parsed one by one with ParseConfigSources
+	if configFilePath != "" {
+		configArguments := []string{}
+		flagSet.Visit(func(f *flag.Flag) {
+			if !slices.Contains(configGlobalFlags, f.Name) {
+				logger.Error("Cannot simultaneously set --config and --" + f.Name + ", set it for each source in the configuration file")
+				os.Exit(-1)
+			}
+			if f.Name != "config" {
+				configArguments = append(configArguments, "--"+f.Name+"="+f.Value.String())
+			}
+		})
+		if len(positionalArgs) > 0 {
+			logger.Error("Cannot simultaneously set --config and a path to scan")
+			os.Exit(-1)
+		}
+		return CLIArgs{LogLevel: logLevel, ConfigFilePath: configFilePath, ConfigArguments: configArguments}
+	}
+
+	// a single positional argument is the path to scan, e.g. go-cloc scan ./src
+	if len(positionalArgs) > 0 {
+		if command != SCAN || len(positionalArgs) > 1 || localScanFilePath != "" || (mode != LOCAL && mode != LOCALREPOS) {
+			logger.Error("Unexpected arguments ", positionalArgs)
+			os.Exit(-1)
+		}
+		localScanFilePath = positionalArgs[0]
+	}
+
+	// discover writes an inventory of the repositories, unless they are only listed
+	if command == DISCOVER {
+		if mode == LOCAL {
+			logger.Error("Mode ", mode, " does not support discover, use : go-cloc scan <path>")
+			os.Exit(-1)
+		}
+		dryRun = !listRepos
+	}
+
 	// print out arguments
 	logger.Debug("Mode: ", mode)
 	logger.Debug("clone-repo-using-zip: ", cloneRepoUsingZip)
 	logger.Debug("dump-csvs: ", dumpCSVs)
 
+	// configure the proxy and TLS settings before any network call is made
+	err := httpclient.Configure(transportOptions)
+	if err != nil {
+		logger.Error("Invalid network configuration: ", err)
+		os.Exit(-1)
+	}
+
 	// validate mandatory arguments
 	logger.Debug("Validating mandatory arguments")
 	if mode == LOCAL {
@@ -82,14 +316,191 @@ func ParseArgsFromCLI() CLIArgs {
 			logger.Error("Mode ", mode, " requires : --local-file-path")
 			os.Exit(-1)
 		}
+	} else if mode == LOCALREPOS {
+		if localScanFilePath == "" {
+			logger.Error("Mode ", mode, " requires : --local-file-path")
+			os.Exit(-1)
+		}
+		fileInfo, err := os.Stat(localScanFilePath)
+		if err != nil || !fileInfo.IsDir() {
+			logger.Error("Mode ", mode, " requires --local-file-path to be a directory")
+			os.Exit(-1)
+		}
+	} else if mode == GIT {
+		// credentials are optional since they can be part of each URL or handled by SSH
+		if repositoriesFilePath == "" && repositoriesInventoryPath == "" {
+			logger.Error("Mode ", mode, " requires : --repositories-file")
+			os.Exit(-1)
+		}
+		if cloneRepoUsingZip {
+			logger.Error("Mode ", mode, " does not support --clone-repo-using-zip")
+			os.Exit(-1)
+		}
+	} else if mode == GITHUB && githubAppId != "" {
+		// a GitHub App installation replaces the personal access token
+		if githubAppPrivateKeyPath == "" {
+			logger.Error("--github-app-id requires : --github-app-private-key-file")
+			os.Exit(-1)
+		}
+		if githubAppInstallationId == "" && organization == "" {
+			logger.Error("--github-app-id requires : --github-app-installation-id or --organization")
+			os.Exit(-1)
+		}
 	} else {
-		if organization == "" || accessToken == "" {
-			logger.Error("Mode ", mode, " requires : --organization & --accessToken")
+		// the organization is not needed when the repositories come from an inventory
+		if organization == "" && repositoriesInventoryPath == "" {
+			logger.Error("Mode ", mode, " requires : --organization")
 			os.Exit(-1)
 		}
+		resolvedAccessToken, err := ResolveAccessToken(mode, baseURL, accessToken, accessTokenFilePath, accessTokenEnv)
+		if err != nil {
+			logger.Error(err)
+			os.Exit(-1)
+		}
+		accessToken = resolvedAccessToken
+		if mode == GITEA && baseURL == "" {
+			logger.Error("Mode ", mode, " requires : --base-url")
+			os.Exit(-1)
+		}
+	}
+
+	if githubDiscovery != github.GRAPHQL && githubDiscovery != github.REST {
+		logger.Error("Invalid --github-discovery ", githubDiscovery, ", expected graphql or rest")
+		os.Exit(-1)
 	}
+	if githubAppId != "" && mode != GITHUB {
+		logger.Error("Mode ", mode, " does not support --github-app-id")
+		os.Exit(-1)
+	}
+
+	// split organizations, e.g. org1,org2
+	organizations := SplitCommaSeparatedList(organization)
+	logger.Debug("Organizations: ", organizations)
+	// a GitHub App installation, and so its token, belongs to a single account
+	if githubAppId != "" && len(organizations) > 1 {
+		logger.Error("--github-app-id supports a single --organization, run go-cloc once per organization")
+		os.Exit(-1)
+	}
+	logger.Debug("Include Projects: ", includeProjects)
+	logger.Debug("Exclude Projects: ", excludeProjects)
 
 	// validate optional arguments
+	if pushedSince != "" {
+		pushedSinceTime, err := time.Parse(time.DateOnly, pushedSince)
+		if err != nil {
+			logger.Error("Invalid --pushed-since ", pushedSince, ", expected the format YYYY-MM-DD")
+			os.Exit(-1)
+		}
+		repoFilter.PushedSince = pushedSinceTime
+	}
+	logger.Debug("Repository filter: ", repoFilter)
+
+	// validate inventory arguments
+	if inventoryFormat != inventory.CSV && inventoryFormat != inventory.JSON {
+		logger.Error("Invalid --inventory-format ", inventoryFormat, ", expected csv or json")
+		os.Exit(-1)
+	}
+	if dryRun && !dumpCSVs && inventoryFilePath == "" {
+		logger.Error("--dry-run with --dump-csvs=false requires : --inventory-file")
+		os.Exit(-1)
+	}
+	// the inventory is read back based on its extension, so it is written the same way
+	if inventoryFilePath != "" {
+		inventoryFormatSet := false
+		flagSet.Visit(func(f *flag.Flag) {
+			inventoryFormatSet = inventoryFormatSet || f.Name == "inventory-format"
+		})
+		extensionFormat := inventory.FormatFromPath(inventoryFilePath)
+		if inventoryFormatSet && inventoryFormat != extensionFormat {
+			logger.Error("--inventory-format ", inventoryFormat, " does not match the extension of --inventory-file ", inventoryFilePath)
+			os.Exit(-1)
+		}
+		inventoryFormat = extensionFormat
+	}
+
+	// validate branch selection
+	if largestBranch {
+		if branches != "" {
+			logger.Error("Cannot simultaneously set --largest-branch and --branches")
+			os.Exit(-1)
+		}
+		branches = "*"
+	}
+	if ref != "" && branches != "" {
+		logger.Error("Cannot simultaneously set --ref and --branches / --largest-branch")
+		os.Exit(-1)
+	}
+	if (ref != "" || branches != "") && (mode == LOCAL || mode == LOCALREPOS) {
+		logger.Error("Mode ", mode, " does not support --ref, --branches or --largest-branch")
+		os.Exit(-1)
+	}
+	logger.Debug("Ref: ", ref, " Branches: ", branches)
+
+	// override the default API rate of the DevOps platform
+	if requestsPerSecond < 0 {
+		logger.Error("Invalid --requests-per-second ", requestsPerSecond, ", expected a positive number")
+		os.Exit(-1)
+	}
+	if requestsPerSecond > 0 && !httpclient.SetRequestsPerSecond(mode, requestsPerSecond) {
+		logger.Warn("Mode ", mode, " does not use an API, ignoring --requests-per-second")
+	}
+
+	// validate clone protocol
+	if cloneProtocol != clone.HTTPS && cloneProtocol != clone.SSH {
+		logger.Error("Invalid --clone-protocol ", cloneProtocol, ", expected https or ssh")
+		os.Exit(-1)
+	}
+	if cloneProtocol == clone.SSH && cloneRepoUsingZip {
+		logger.Error("Cannot simultaneously set --clone-protocol ssh and --clone-repo-using-zip")
+		os.Exit(-1)
+	}
+	logger.Debug("Clone protocol: ", cloneProtocol)
+	if sshOptions.KeyPassphrase == "" && sshKeyPassphraseEnv != "" {
+		// an explicitly named variable must be set
+		sshOptions.KeyPassphrase = os.Getenv(sshKeyPassphraseEnv)
+		if sshOptions.KeyPassphrase == "" {
+			logger.Error("Environment variable ", sshKeyPassphraseEnv, " of --ssh-key-passphrase-env is not set")
+			os.Exit(-1)
+		}
+	}
+
+	// validate estimate mode, the language statistics only cover the default branch
+	var estimateFactors estimate.Factors
+	if estimateLOC {
+		if mode != GITHUB && mode != GITLAB && mode != BITBUCKET && mode != GITEA {
+			logger.Error("Mode ", mode, " does not support --estimate")
+			os.Exit(-1)
+		}
+		if ref != "" || branches != "" {
+			logger.Error("Cannot simultaneously set --estimate and --ref, --branches or --largest-branch")
+			os.Exit(-1)
+		}
+		estimateFactors, err = estimate.LoadFactors(calibrationFilePath)
+		if err != nil {
+			logger.Error("Invalid --calibration-file: ", err)
+			os.Exit(-1)
+		}
+	} else if calibrationFilePath != "" {
+		logger.Error("--calibration-file requires : --estimate")
+		os.Exit(-1)
+	}
+	logger.Debug("Estimate: ", estimateLOC, " Calibration file: ", calibrationFilePath)
+
+	// validate the format of the combined report
+	if !slices.Contains(report.Formats, format) {
+		logger.Error("Invalid --format ", format, ", expected one of ", strings.Join(report.Formats, ", "))
+		os.Exit(-1)
+	}
+	if format == report.CSV && outputFilePath != "" {
+		logger.Error("--output-file requires : --format other than csv")
+		os.Exit(-1)
+	}
+	if format != report.CSV && (estimateLOC || dryRun || listRepos) {
+		logger.Error("Cannot simultaneously set --format ", format, " and --estimate, --dry-run or --list-repos")
+		os.Exit(-1)
+	}
+	validateMarkdownOptions(format, baselinePath, topFiles)
+	logger.Debug("Format: ", format, " Output file: ", outputFilePath)
 
 	// parse ignore patterns
 	ignorePatterns := []string{}
@@ -101,21 +512,21 @@ func ParseArgsFromCLI() CLIArgs {
 	}
 
 	// parse exclude repositories
-	excludeRepositories := []string{}
+	excludeRepositories := []devops.RepoRule{}
 	if excludeRepositoriesFilePath != "" {
 		logger.Debug("Parsing exclude-repositories-file ", excludeRepositoriesFilePath)
-		excludeRepositories = scanner.ReadIgnoreFile(excludeRepositoriesFilePath)
+		excludeRepositories = readRepoRulesFile(excludeRepositoriesFilePath)
 		logger.Debug("Successfully read in the exclude-repositories-file ", excludeRepositoriesFilePath)
-		logger.Debug("Exclude Repositories: ", excludeRepositories)
+		logger.Debug("Exclude Repositories: ", len(excludeRepositories), " rules")
 	}
 
 	// parse include repositories
-	includeRepositories := []string{}
+	includeRepositories := []devops.RepoRule{}
 	if includeRepositoriesFilePath != "" {
 		logger.Debug("Parsing include-repositories-file ", includeRepositoriesFilePath)
-		includeRepositories = scanner.ReadIgnoreFile(includeRepositoriesFilePath)
+		includeRepositories = readRepoRulesFile(includeRepositoriesFilePath)
 		logger.Debug("Successfully read in the include-repositories-file ", includeRepositoriesFilePath)
-		logger.Debug("Include Repositories: ", includeRepositories)
+		logger.Debug("Include Repositories: ", len(includeRepositories), " rules")
 	}
 
 	if !dumpCSVs && resultsDirectoryPath != "" {
@@ -130,18 +541,98 @@ func ParseArgsFromCLI() CLIArgs {
 	logger.Debug("Results Directory Path: ", resultsDirectoryPath)
 
 	args := CLIArgs{
-		LogLevel:             logLevel,
-		Mode:                 mode,
-		LocalScanFilePath:    localScanFilePath,
-		AccessToken:          accessToken,
-		Organization:         organization,
-		IgnorePatterns:       ignorePatterns,
-		ExcludeRepositories:  excludeRepositories,
-		IncludeRepositories:  includeRepositories,
-		CloneRepoUsingZip:    cloneRepoUsingZip,
-		DumpCSVs:             dumpCSVs,
-		ResultsDirectoryPath: resultsDirectoryPath,
+		LogLevel:                  logLevel,
+		Mode:                      mode,
+		LocalScanFilePath:         localScanFilePath,
+		LocalGitReposOnly:         localGitReposOnly,
+		AccessToken:               accessToken,
+		Organization:              organization,
+		Organizations:             organizations,
+		BaseURL:                   baseURL,
+		GithubAppId:               githubAppId,
+		GithubAppInstallationId:   githubAppInstallationId,
+		GithubAppPrivateKeyPath:   githubAppPrivateKeyPath,
+		GithubDiscovery:           githubDiscovery,
+		RepositoriesFilePath:      repositoriesFilePath,
+		IgnorePatterns:            ignorePatterns,
+		ExcludeRepositories:       excludeRepositories,
+		IncludeRepositories:       includeRepositories,
+		ListRepos:                 listRepos,
+		DryRun:                    dryRun,
+		InventoryFormat:           inventoryFormat,
+		InventoryFilePath:         inventoryFilePath,
+		RepositoriesInventoryPath: repositoriesInventoryPath,
+		RepoFilter:                repoFilter,
+		IncludeProjects:           includeProjects,
+		ExcludeProjects:           excludeProjects,
+		Ref:                       ref,
+		Branches:                  branches,
+		CloneRepoUsingZip:         cloneRepoUsingZip,
+		CloneProtocol:             cloneProtocol,
+		SSHOptions:                sshOptions,
+		Estimate:                  estimateLOC,
+		EstimateFactors:           estimateFactors,
+		DumpCSVs:                  dumpCSVs,
+		GroupByProject:            groupByProject,
+		ResultsDirectoryPath:      resultsDirectoryPath,
+		Format:                    format,
+		OutputFilePath:            outputFilePath,
+		BaselinePath:              baselinePath,
+		TopFiles:                  topFiles,
 	}
 
 	return args
 }
+
+// ParseConfigSources parses every source of the configuration file as if its settings were passed as flags.
+// Returns the arguments of the combined report, then the arguments of each source
+func ParseConfigSources(args CLIArgs) (CLIArgs, []CLIArgs) {
+	runConfig, err := config.Load(args.ConfigFilePath)
+	if err != nil {
+		logger.Error(err)
+		os.Exit(-1)
+	}
+
+	sourcesArgs := []CLIArgs{}
+	sourceNames := []string{}
+	modes := []string{}
+	resultsArguments := []string{}
+	for _, source := range runConfig.Sources {
+		logger.Info("Parsing source ", source.Name, " of ", args.ConfigFilePath)
+		// flags set on the command line take precedence over the output settings of the configuration file
+		arguments := slices.Concat(source.Arguments(), runConfig.Output.Arguments(), args.ConfigArguments, resultsArguments)
+		sourceArgs := ParseScanArgs(SCAN, arguments)
+		// every source writes to the same results directory
+		if sourceArgs.ResultsDirectoryPath != "" {
+			resultsArguments = []string{"--results-directory-path=" + sourceArgs.ResultsDirectoryPath}
+		}
+
+		// add the inline rules and ignore patterns to those read from files
+		includeRepositories, excludeRepositories, _ := source.RepoRules()
+		sourceArgs.IncludeRepositories = append(sourceArgs.IncludeRepositories, includeRepositories...)
+		sourceArgs.ExcludeRepositories = append(sourceArgs.ExcludeRepositories, excludeRepositories...)
+		sourceArgs.IgnorePatterns = append(sourceArgs.IgnorePatterns, source.IgnorePatterns...)
+		sourceArgs.SourceName = source.Name
+
+		sourcesArgs = append(sourcesArgs, sourceArgs)
+		sourceNames = append(sourceNames, source.Name)
+		if !slices.Contains(modes, sourceArgs.Mode) {
+			modes = append(modes, sourceArgs.Mode)
+		}
+	}
+
+	outputArgs := CLIArgs{
+		LogLevel:             args.LogLevel,
+		Mode:                 strings.Join(modes, ","),
+		Organization:         strings.Join(sourceNames, ","),
+		DumpCSVs:             sourcesArgs[0].DumpCSVs,
+		GroupByProject:       sourcesArgs[0].GroupByProject,
+		ResultsDirectoryPath: sourcesArgs[0].ResultsDirectoryPath,
+		Format:               sourcesArgs[0].Format,
+		OutputFilePath:       sourcesArgs[0].OutputFilePath,
+		BaselinePath:         sourcesArgs[0].BaselinePath,
+		TopFiles:             sourcesArgs[0].TopFiles,
+		ConfigFilePath:       args.ConfigFilePath,
+	}
+	return outputArgs, sourcesArgs
+}